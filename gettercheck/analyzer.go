@@ -1,10 +1,16 @@
 package gettercheck
 
 import (
+	"flag"
+	"fmt"
 	"go/token"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
 var Analyzer = &analysis.Analyzer{
@@ -14,31 +20,131 @@ var Analyzer = &analysis.Analyzer{
 	ResultType: reflect.TypeOf(Result{}),
 }
 
+var (
+	// analyzerProfiles holds the profiles selected with the Analyzer's -profiles flag.
+	analyzerProfiles = DefaultProfiles()
+	// analyzerIgnores holds the rules set with the Analyzer's -ignore flag.
+	analyzerIgnores Ignores
+	// analyzerUnusedDirectives is set with the Analyzer's -unused-directives flag.
+	analyzerUnusedDirectives bool
+	// analyzerConfig is the configuration file set with the Analyzer's -config flag.
+	analyzerConfig string
+)
+
 func init() {
+	Analyzer.Flags.Var(&analyzerIgnores, "ignore", "comma separated list of pkg:regex pairs; findings for fields whose Message.Field name matches the regex in packages matching pkg are ignored")
+	Analyzer.Flags.BoolVar(&analyzerUnusedDirectives, "unused-directives", false, "report //gettercheck: directives that are malformed, expired or don't suppress anything")
+	Analyzer.Flags.Var(&analyzerProfiles, "profiles", "comma separated list of generator profiles to check: "+strings.Join(ProfileNames(), ", "))
+	Analyzer.Flags.StringVar(&analyzerConfig, "config", "", "configuration file to use instead of the "+ConfigFile+" file of the module")
+}
+
+// analyzerConfigs caches the configuration loaded for each -config flag and
+// package directory, as LoadConfig would otherwise read it for every package.
+var analyzerConfigs sync.Map
+
+type analyzerConfigKey struct {
+	filename, dir string
+}
+
+type loadedConfig struct {
+	config *Config
+	err    error
+}
+
+// loadAnalyzerConfig returns the configuration of the package in dir, loading
+// it only the first time.
+func loadAnalyzerConfig(dir string) (*Config, error) {
+	key := analyzerConfigKey{analyzerConfig, dir}
+	loaded, ok := analyzerConfigs.Load(key)
+	if !ok {
+		config, err := LoadConfig(analyzerConfig, dir)
+		loaded, _ = analyzerConfigs.LoadOrStore(key, loadedConfig{config, err})
+	}
+	return loaded.(loadedConfig).config, loaded.(loadedConfig).err
+}
+
+// analyzerChecker returns a Checker holding the settings of the configuration
+// file of the package being analyzed, overridden by the flags that were set.
+func analyzerChecker(pass *analysis.Pass) (*Checker, error) {
+	c := &Checker{}
+	if len(pass.Files) > 0 {
+		dir := filepath.Dir(pass.Fset.Position(pass.Files[0].Pos()).Filename)
+		config, err := loadAnalyzerConfig(dir)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			if err := config.Apply(c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	pass.Analyzer.Flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "profiles":
+			c.Profiles = analyzerProfiles
+		case "ignore":
+			c.Exclusions.Ignore = append(c.Exclusions.Ignore, analyzerIgnores...)
+		case "unused-directives":
+			c.UnusedDirectives = analyzerUnusedDirectives
+		}
+	})
+	return c, nil
 }
 
 func runAnalyzer(pass *analysis.Pass) (interface{}, error) {
+	c, err := analyzerChecker(pass)
+	if err != nil {
+		return nil, err
+	}
 
 	var allErrors []UnusedGetterError
 	for _, f := range pass.Files {
+		settings := c.settings(pass.Fset.Position(f.Pos()).Filename)
+		if !settings.unusedGetter || settings.shouldSkipFile(f) {
+			continue
+		}
 		v := &visitor{
+			types:     pass.Pkg,
 			typesInfo: pass.TypesInfo,
 			fset:      pass.Fset,
 			lines:     make(map[string][]string),
-			errors:    nil,
+			profiles:  settings.profiles,
+			ignores:   settings.ignores,
+			pkgPath:   pass.Pkg.Path(),
 		}
 
-		astutil.Apply(f, v.Visit,nil)
-		//ast.Walk(v, f)
+		astutil.Apply(f, v.Visit, nil)
+		directives := parseDirectives(pass.Fset, f, time.Now())
+		v.findings = directives.suppress(pass.Fset, v.findings)
+		if settings.unusedDirective {
+			for _, p := range directives.problems() {
+				pass.Reportf(p.d.comment.Slash, "%s: %s", p.d.comment.Text, p.problem)
+			}
+		}
 
-		for _, err := range v.errors {
-			pass.Report(analysis.Diagnostic{
-				Pos:     token.Pos(int(f.Pos()) + err.Pos.Offset),
-				Message: "unused getter",
-			})
+		for _, finding := range v.findings {
+			getter := finding.getter
+			d := analysis.Diagnostic{
+				Pos:     finding.pos,
+				End:     finding.end,
+				Message: unusedGetterMessage(finding.call(), finding.field, finding.reason),
+				Related: []analysis.RelatedInformation{{
+					Pos:     getter.Pos(),
+					End:     getter.Pos() + token.Pos(len(getter.Name())),
+					Message: fmt.Sprintf("getter %s declared here", getter.Name()),
+				}},
+			}
+			if finding.fixable() {
+				d.SuggestedFixes = []analysis.SuggestedFix{{
+					Message:   fmt.Sprintf("Use %s", finding.call()),
+					TextEdits: []analysis.TextEdit{finding.edit()},
+				}}
+			}
+			pass.Report(d)
 		}
 
-		allErrors = append(allErrors, v.errors...)
+		allErrors = append(allErrors, v.unusedGetterErrors()...)
 	}
 
 	return Result{UnusedGetterError: allErrors}, nil
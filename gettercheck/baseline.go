@@ -0,0 +1,125 @@
+package gettercheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// baselineVersion is the version of the baseline file format.
+const baselineVersion = 1
+
+// Fingerprint identifies an unused getter error without relying on its
+// position, so that it survives unrelated edits to its file.
+type Fingerprint struct {
+	// Package is the import path of the package the error is in.
+	Package string `json:"package"`
+	// Function is the function or method the error is in, such as F, T.M or
+	// (*T).M, or the empty string at package level.
+	Function string `json:"function"`
+	// Selector is the text of the selector expression accessing the field.
+	Selector string `json:"selector"`
+}
+
+// Baseline records known unused getter errors, so that only new ones are
+// reported.
+//
+// Errors are counted by fingerprint: if a function accesses the same field in
+// the same way twice, the baseline accepts two such errors, and a third one is
+// reported.
+type Baseline struct {
+	counts map[Fingerprint]int
+}
+
+type baselineFile struct {
+	Version  int             `json:"version"`
+	Findings []baselineEntry `json:"findings"`
+}
+
+type baselineEntry struct {
+	Fingerprint
+	Count int `json:"count"`
+}
+
+// NewBaseline returns a baseline accepting errs.
+func NewBaseline(errs []UnusedGetterError) *Baseline {
+	b := &Baseline{counts: make(map[Fingerprint]int)}
+	for _, err := range errs {
+		b.counts[err.Fingerprint]++
+	}
+	return b
+}
+
+// ReadBaseline reads a baseline written by WriteFile.
+func ReadBaseline(filename string) (*Baseline, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var f baselineFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if f.Version != baselineVersion {
+		return nil, fmt.Errorf("%s: unsupported baseline version %d", filename, f.Version)
+	}
+	b := &Baseline{counts: make(map[Fingerprint]int)}
+	for _, entry := range f.Findings {
+		b.counts[entry.Fingerprint] += entry.Count
+	}
+	return b, nil
+}
+
+// WriteFile writes the baseline to filename. The entries are sorted so that
+// the file only changes along with the errors it records.
+func (b *Baseline) WriteFile(filename string) error {
+	f := baselineFile{Version: baselineVersion, Findings: []baselineEntry{}}
+	for fingerprint, count := range b.counts {
+		f.Findings = append(f.Findings, baselineEntry{fingerprint, count})
+	}
+	sort.Slice(f.Findings, func(i, j int) bool {
+		fi, fj := f.Findings[i], f.Findings[j]
+		if fi.Package != fj.Package {
+			return fi.Package < fj.Package
+		}
+		if fi.Function != fj.Function {
+			return fi.Function < fj.Function
+		}
+		return fi.Selector < fj.Selector
+	})
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// Filter returns r without the errors accepted by the baseline. The receiver
+// and r remain unmodified.
+func (b *Baseline) Filter(r Result) Result {
+	remaining := b.remaining()
+	filtered := r
+	filtered.UnusedGetterError = nil
+	for _, err := range r.UnusedGetterError {
+		if remaining[err.Fingerprint] > 0 {
+			remaining[err.Fingerprint]--
+			continue
+		}
+		filtered.UnusedGetterError = append(filtered.UnusedGetterError, err)
+	}
+	return filtered
+}
+
+// remaining returns a copy of the number of errors the baseline accepts by
+// fingerprint, to be decremented as they are found, or nil if b is nil.
+func (b *Baseline) remaining() map[Fingerprint]int {
+	if b == nil {
+		return nil
+	}
+	remaining := make(map[Fingerprint]int, len(b.counts))
+	for fingerprint, count := range b.counts {
+		remaining[fingerprint] = count
+	}
+	return remaining
+}
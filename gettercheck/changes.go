@@ -0,0 +1,152 @@
+package gettercheck
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ChangedLines are the lines a diff adds or modifies, by absolute filename.
+type ChangedLines map[string]map[int]bool
+
+// ParseUnifiedDiff reads the unified diff from r, such as the output of git
+// diff, and returns the lines it adds or modifies in the new version of each
+// file. The file names in the diff are relative to dir, and the b/ prefix git
+// adds is removed from them.
+func ParseUnifiedDiff(r io.Reader, dir string) (ChangedLines, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(ChangedLines)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var (
+		lines              map[int]bool
+		lineNo             int
+		oldCount, newCount int
+	)
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		// Inside a hunk, lines are counted so that removed lines starting
+		// with "--" aren't mistaken for headers.
+		if oldCount > 0 || newCount > 0 {
+			if text == "" {
+				// Some tools strip the space of empty context lines.
+				text = " "
+			}
+			switch text[0] {
+			case ' ':
+				oldCount--
+				newCount--
+				lineNo++
+			case '-':
+				oldCount--
+			case '+':
+				newCount--
+				if lines != nil {
+					lines[lineNo] = true
+				}
+				lineNo++
+			case '\\':
+			default:
+				return nil, fmt.Errorf("line %d: unexpected line in hunk: %q", n, text)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			name := strings.TrimPrefix(text, "+++ ")
+			if i := strings.IndexByte(name, '\t'); i >= 0 {
+				name = name[:i]
+			}
+			if unquoted, err := strconv.Unquote(name); err == nil {
+				name = unquoted
+			}
+			if name == "/dev/null" {
+				lines = nil
+				continue
+			}
+			name = strings.TrimPrefix(name, "b/")
+			filename := filepath.Join(dir, filepath.FromSlash(name))
+			lines = changed[filename]
+			if lines == nil {
+				lines = make(map[int]bool)
+				changed[filename] = lines
+			}
+		case strings.HasPrefix(text, "@@ "):
+			var err error
+			lineNo, oldCount, newCount, err = parseHunkHeader(text)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// parseHunkHeader parses a header of the form @@ -l,s +l,s @@ and returns the
+// first line of the new version, and the number of lines of each version.
+func parseHunkHeader(header string) (start, oldCount, newCount int, err error) {
+	fields := strings.Fields(header)
+	if len(fields) < 4 || !strings.HasPrefix(fields[3], "@@") {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+	_, oldCount, err = parseHunkRange(fields[1], "-")
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header %q: %v", header, err)
+	}
+	start, newCount, err = parseHunkRange(fields[2], "+")
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header %q: %v", header, err)
+	}
+	return start, oldCount, newCount, nil
+}
+
+// parseHunkRange parses a range of the form -l,s or -l, where s defaults to 1.
+func parseHunkRange(s, prefix string) (start, count int, err error) {
+	if !strings.HasPrefix(s, prefix) {
+		return 0, 0, fmt.Errorf("range %q doesn't start with %s", s, prefix)
+	}
+	s = strings.TrimPrefix(s, prefix)
+	count = 1
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		count, err = strconv.Atoi(s[i+1:])
+		if err != nil {
+			return 0, 0, err
+		}
+		s = s[:i]
+	}
+	start, err = strconv.Atoi(s)
+	return start, count, err
+}
+
+// Contains reports whether line of filename was added or modified.
+func (c ChangedLines) Contains(filename string, line int) bool {
+	return c[filepath.Clean(filename)][line]
+}
+
+// Filter returns r without the unused getter and directive errors outside of
+// the changed lines. The receiver and r remain unmodified.
+func (c ChangedLines) Filter(r Result) Result {
+	filtered := r
+	filtered.UnusedGetterError = nil
+	for _, err := range r.UnusedGetterError {
+		if c.Contains(err.Pos.Filename, err.Pos.Line) {
+			filtered.UnusedGetterError = append(filtered.UnusedGetterError, err)
+		}
+	}
+	filtered.DirectiveErrors = nil
+	for _, err := range r.DirectiveErrors {
+		if c.Contains(err.Pos.Filename, err.Pos.Line) {
+			filtered.DirectiveErrors = append(filtered.DirectiveErrors, err)
+		}
+	}
+	return filtered
+}
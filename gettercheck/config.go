@@ -0,0 +1,276 @@
+package gettercheck
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the name of the configuration file LoadConfig looks for.
+const ConfigFile = ".gettercheck.yaml"
+
+// The rules that can be enabled or disabled in a configuration file.
+const (
+	// RuleUnusedGetter reports direct field accesses that should use a
+	// getter. It is enabled by default.
+	RuleUnusedGetter = "unused-getter"
+	// RuleUnusedDirective reports malformed, expired and unused suppression
+	// directives, see Checker.UnusedDirectives.
+	RuleUnusedDirective = "unused-directive"
+)
+
+// RuleNames returns the names of the rules, sorted.
+func RuleNames() []string {
+	return []string{RuleUnusedDirective, RuleUnusedGetter}
+}
+
+// Config is the project configuration read from a .gettercheck.yaml file:
+//
+//	profiles: [protobuf, thrift]
+//	ignore-tests: false
+//	ignore-generated: true
+//	ignore:
+//	  - internal/legacy:\.Name$
+//	rules:
+//	  unused-directive: true
+//	format: text
+//	format-template: vim
+//	overrides:
+//	  - path: internal/migration
+//	    rules:
+//	      unused-getter: false
+type Config struct {
+	// Profiles are the names of the generator profiles to check.
+	Profiles []string `yaml:"profiles"`
+	// Ignore lists `[pkg:]field` pairs of regular expressions, see Ignore.
+	Ignore          []string `yaml:"ignore"`
+	IgnoreTests     bool     `yaml:"ignore-tests"`
+	IgnoreGenerated bool     `yaml:"ignore-generated"`
+	// Rules enables or disables rules by name, see RuleNames.
+	Rules map[string]bool `yaml:"rules"`
+	// Format is the name of the output format of the command-line tool.
+	Format string `yaml:"format"`
+	// FormatTemplate is the template the text format prints each finding
+	// with, or the name of one of Templates.
+	FormatTemplate string `yaml:"format-template"`
+	// Overrides change the settings for some directories.
+	Overrides []ConfigOverride `yaml:"overrides"`
+
+	// Dir is the directory the configuration was read from. The paths of
+	// overrides are relative to it.
+	Dir string `yaml:"-"`
+}
+
+// ConfigOverride changes the settings for the files in a directory and its
+// subdirectories.
+type ConfigOverride struct {
+	// Path is the directory, relative to the configuration file.
+	Path string `yaml:"path"`
+	// Profiles replace the configured profiles, if not empty.
+	Profiles []string `yaml:"profiles"`
+	// Ignore is added to the configured ignore rules.
+	Ignore          []string        `yaml:"ignore"`
+	IgnoreGenerated *bool           `yaml:"ignore-generated"`
+	Rules           map[string]bool `yaml:"rules"`
+}
+
+// LoadConfig reads the configuration from filename, if it isn't empty.
+// Otherwise it looks for a .gettercheck.yaml file in dir and its parents, up
+// to the root of the module dir belongs to. It returns nil if there is none.
+func LoadConfig(filename, dir string) (*Config, error) {
+	if filename != "" {
+		return ReadConfig(filename)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		filename := filepath.Join(dir, ConfigFile)
+		if _, err := os.Stat(filename); err == nil {
+			return ReadConfig(filename)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return nil, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// ReadConfig reads and validates the configuration in filename.
+func ReadConfig(filename string) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	config.Dir, err = filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := config.overrides(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return &config, nil
+}
+
+// Apply configures c with the settings of the configuration. Settings that
+// aren't in the configuration are left alone.
+func (config *Config) Apply(c *Checker) error {
+	overrides, err := config.overrides()
+	if err != nil {
+		return err
+	}
+	if config.IgnoreTests {
+		c.Exclusions.TestFiles = true
+	}
+	if config.IgnoreGenerated {
+		c.Exclusions.GeneratedFiles = true
+	}
+	// The top-level settings are the first override, which applies to
+	// every file.
+	top := overrides[0]
+	if len(top.Profiles) > 0 {
+		c.Profiles = top.Profiles
+	}
+	c.Exclusions.Ignore = append(c.Exclusions.Ignore, top.Ignore...)
+	if enabled, ok := top.Rules[RuleUnusedDirective]; ok {
+		c.UnusedDirectives = enabled
+		delete(top.Rules, RuleUnusedDirective)
+	}
+	if len(top.Rules) > 0 {
+		c.Overrides = append(c.Overrides, Override{Rules: top.Rules})
+	}
+	c.Overrides = append(c.Overrides, overrides[1:]...)
+	return nil
+}
+
+// overrides converts the configuration to overrides: one for the top-level
+// settings, followed by one for each of the configuration's overrides.
+func (config *Config) overrides() ([]Override, error) {
+	top, err := newOverride("", config.Profiles, config.Ignore, nil, config.Rules)
+	if err != nil {
+		return nil, err
+	}
+	overrides := []Override{top}
+	for _, o := range config.Overrides {
+		if o.Path == "" {
+			return nil, fmt.Errorf("override without a path")
+		}
+		dir := filepath.Join(config.Dir, filepath.FromSlash(o.Path))
+		override, err := newOverride(dir, o.Profiles, o.Ignore, o.IgnoreGenerated, o.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("override for %s: %v", o.Path, err)
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+}
+
+func newOverride(dir string, profiles, ignore []string, ignoreGenerated *bool, rules map[string]bool) (Override, error) {
+	o := Override{Dir: dir, GeneratedFiles: ignoreGenerated}
+	if len(profiles) > 0 {
+		if err := o.Profiles.Set(strings.Join(profiles, ",")); err != nil {
+			return Override{}, err
+		}
+	}
+	for _, s := range ignore {
+		i, err := ParseIgnore(s)
+		if err != nil {
+			return Override{}, err
+		}
+		o.Ignore = append(o.Ignore, i)
+	}
+	for name, enabled := range rules {
+		i := sort.SearchStrings(RuleNames(), name)
+		if i == len(RuleNames()) || RuleNames()[i] != name {
+			return Override{}, fmt.Errorf("unknown rule %q, must be one of %s", name, strings.Join(RuleNames(), ", "))
+		}
+		if o.Rules == nil {
+			o.Rules = make(map[string]bool)
+		}
+		o.Rules[name] = enabled
+	}
+	return o, nil
+}
+
+// Override changes the settings of a Checker for the files in Dir and its
+// subdirectories, or for all files if Dir is empty. When several overrides
+// apply to a file, later ones take precedence.
+type Override struct {
+	Dir string
+	// Profiles replace the checker's profiles, if not empty.
+	Profiles Profiles
+	// Ignore is added to the checker's ignore rules.
+	Ignore Ignores
+	// GeneratedFiles replaces Exclusions.GeneratedFiles, if not nil.
+	GeneratedFiles *bool
+	// Rules enables or disables rules by name, see RuleNames.
+	Rules map[string]bool
+}
+
+// applies reports whether the override applies to filename.
+func (o Override) applies(filename string) bool {
+	if o.Dir == "" {
+		return true
+	}
+	rel, err := filepath.Rel(o.Dir, filename)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fileSettings are the settings a file is checked with, once the overrides
+// applying to it are taken into account.
+type fileSettings struct {
+	profiles        Profiles
+	ignores         Ignores
+	generatedFiles  bool
+	unusedGetter    bool
+	unusedDirective bool
+}
+
+func (c *Checker) settings(filename string) fileSettings {
+	s := fileSettings{
+		profiles:        c.profiles(),
+		ignores:         c.Exclusions.Ignore,
+		generatedFiles:  c.Exclusions.GeneratedFiles,
+		unusedGetter:    true,
+		unusedDirective: c.UnusedDirectives,
+	}
+	for _, o := range c.Overrides {
+		if !o.applies(filename) {
+			continue
+		}
+		if len(o.Profiles) > 0 {
+			s.profiles = o.Profiles
+		}
+		if len(o.Ignore) > 0 {
+			s.ignores = append(append(Ignores(nil), s.ignores...), o.Ignore...)
+		}
+		if o.GeneratedFiles != nil {
+			s.generatedFiles = *o.GeneratedFiles
+		}
+		if enabled, ok := o.Rules[RuleUnusedGetter]; ok {
+			s.unusedGetter = enabled
+		}
+		if enabled, ok := o.Rules[RuleUnusedDirective]; ok {
+			s.unusedDirective = enabled
+		}
+	}
+	return s
+}
@@ -0,0 +1,177 @@
+package gettercheck
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// FileDiff is the change fixing a file's unused getters would make to it.
+type FileDiff struct {
+	Filename string
+
+	// Hunks holds the hunks of the unified diff, without the file headers.
+	Hunks string
+}
+
+// diffLine is a line of a diff: kind is ' ' for an unchanged line, '-' for a
+// deleted one and '+' for an inserted one.
+type diffLine struct {
+	kind byte
+	text string
+	// eol is false for a last line missing its newline.
+	eol bool
+}
+
+// unifiedDiff returns the hunks of a unified diff turning a into b, or the
+// empty string if they are equal.
+//
+// Fixes never add or remove lines, so lines are only ever matched up by
+// position after stripping the common prefix and suffix. That is exact for
+// such changes, and still a valid, if not minimal, diff for any others.
+func unifiedDiff(a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	al, bl := splitLines(a), splitLines(b)
+
+	prefix := 0
+	for prefix < len(al) && prefix < len(bl) && al[prefix] == bl[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(al)-prefix && suffix < len(bl)-prefix && al[len(al)-1-suffix] == bl[len(bl)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, l := range al[:prefix] {
+		lines = append(lines, diffLine{' ', l.text, l.eol})
+	}
+	am, bm := al[prefix:len(al)-suffix], bl[prefix:len(bl)-suffix]
+	if len(am) == len(bm) {
+		for i := 0; i < len(am); {
+			if am[i] == bm[i] {
+				lines = append(lines, diffLine{' ', am[i].text, am[i].eol})
+				i++
+				continue
+			}
+			j := i
+			for j < len(am) && am[j] != bm[j] {
+				j++
+			}
+			lines = appendChange(lines, am[i:j], bm[i:j])
+			i = j
+		}
+	} else {
+		lines = appendChange(lines, am, bm)
+	}
+	for _, l := range al[len(al)-suffix:] {
+		lines = append(lines, diffLine{' ', l.text, l.eol})
+	}
+	return formatHunks(lines)
+}
+
+type line struct {
+	text string
+	eol  bool
+}
+
+func splitLines(src []byte) []line {
+	var lines []line
+	for _, l := range strings.SplitAfter(string(src), "\n") {
+		if l == "" {
+			continue
+		}
+		eol := strings.HasSuffix(l, "\n")
+		lines = append(lines, line{strings.TrimSuffix(l, "\n"), eol})
+	}
+	return lines
+}
+
+func appendChange(lines []diffLine, deleted, inserted []line) []diffLine {
+	for _, l := range deleted {
+		lines = append(lines, diffLine{'-', l.text, l.eol})
+	}
+	for _, l := range inserted {
+		lines = append(lines, diffLine{'+', l.text, l.eol})
+	}
+	return lines
+}
+
+// formatHunks groups lines into hunks, keeping diffContext unchanged lines
+// around every change.
+func formatHunks(lines []diffLine) string {
+	var buf strings.Builder
+	aLine, bLine := 1, 1
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+		// Extend the hunk until there are more than 2*diffContext unchanged
+		// lines before the next change.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end += diffContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+		var aCount, bCount int
+		var body strings.Builder
+		for _, l := range lines[start:end] {
+			if l.kind != '+' {
+				aCount++
+			}
+			if l.kind != '-' {
+				bCount++
+			}
+			body.WriteByte(l.kind)
+			body.WriteString(l.text)
+			body.WriteByte('\n')
+			if !l.eol {
+				body.WriteString("\\ No newline at end of file\n")
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		buf.WriteString(body.String())
+
+		for _, l := range lines[i:end] {
+			if l.kind != '+' {
+				aLine++
+			}
+			if l.kind != '-' {
+				bLine++
+			}
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,210 @@
+package gettercheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+	"time"
+)
+
+const directivePrefix = "//gettercheck:"
+
+// DirectiveError indicates a suppression directive that is malformed, expired
+// or doesn't suppress anything.
+type DirectiveError struct {
+	Pos     token.Position
+	Text    string
+	Problem string
+	// Package is the import path of the package the directive is in.
+	Package string
+}
+
+// directive is a comment suppressing findings. There are two kinds:
+//
+//	//gettercheck:ignore [expires=YYYY-MM-DD] reason
+//	//gettercheck:file-ignore [expires=YYYY-MM-DD] reason
+//
+// An ignore directive suppresses the findings on its own line. It also
+// suppresses those in the statement or declaration it is at the end of the
+// first line of, or that starts on the line following it, if it stands on its
+// own lines. A file-ignore directive suppresses all the findings in the file.
+// Directives stop suppressing anything after their expiry date.
+type directive struct {
+	comment *ast.Comment
+	// line is the line of the comment, and groupEnd the last line of its
+	// comment group.
+	line, groupEnd int
+	// trailing is set if the comment follows code on its line.
+	trailing bool
+
+	fileWide bool
+	reason   string
+	expires  time.Time
+	expired  bool
+	// problem is set if the directive is malformed.
+	problem string
+
+	// from and to delimit the node the directive applies to, if any.
+	from, to token.Pos
+	used     bool
+}
+
+type directives []*directive
+
+// parseDirectives returns the directives in file, as of now.
+func parseDirectives(fset *token.FileSet, file *ast.File, now time.Time) directives {
+	var ds directives
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if !strings.HasPrefix(c.Text, directivePrefix) {
+				continue
+			}
+			d := &directive{
+				comment:  c,
+				line:     fset.Position(c.Slash).Line,
+				groupEnd: fset.Position(cg.End()).Line,
+			}
+			d.parse(strings.TrimPrefix(c.Text, directivePrefix), now)
+			ds = append(ds, d)
+		}
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if _, ok := n.(*ast.File); ok {
+			return true
+		}
+		end := fset.Position(n.End()).Line
+		for _, d := range ds {
+			if end == d.line && n.End() <= d.comment.Slash {
+				d.trailing = true
+			}
+		}
+		return true
+	})
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n.(type) {
+		case ast.Stmt, ast.Decl:
+		default:
+			return n != nil
+		}
+		start := fset.Position(n.Pos()).Line
+		for _, d := range ds {
+			// Nodes are visited outermost first, so the first one found
+			// is the enclosing one.
+			if d.from.IsValid() || d.fileWide {
+				continue
+			}
+			if (d.trailing && start == d.line) || (!d.trailing && start == d.groupEnd+1) {
+				d.from, d.to = n.Pos(), n.End()
+			}
+		}
+		return true
+	})
+	return ds
+}
+
+func (d *directive) parse(text string, now time.Time) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		d.problem = "missing directive name"
+		return
+	}
+	switch fields[0] {
+	case "ignore":
+	case "file-ignore":
+		d.fileWide = true
+	default:
+		d.problem = fmt.Sprintf("unknown directive %q", fields[0])
+		return
+	}
+	fields = fields[1:]
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "expires=") {
+		expires, err := time.Parse("2006-01-02", strings.TrimPrefix(fields[0], "expires="))
+		if err != nil {
+			d.problem = fmt.Sprintf("invalid expiry date: %s", err)
+			return
+		}
+		d.expires = expires
+		// A directive is valid through the day it expires on.
+		d.expired = !now.Before(expires.AddDate(0, 0, 1))
+		fields = fields[1:]
+	}
+	d.reason = strings.Join(fields, " ")
+	if d.reason == "" {
+		d.problem = "missing reason"
+	}
+}
+
+// suppresses reports whether the directive suppresses a finding at pos.
+func (d *directive) suppresses(fset *token.FileSet, pos token.Pos) bool {
+	if d.problem != "" || d.expired {
+		return false
+	}
+	return d.fileWide || fset.Position(pos).Line == d.line || (d.from <= pos && pos < d.to)
+}
+
+// suppress returns the findings that aren't suppressed by any of the
+// directives. It reuses the memory of findings.
+func (ds directives) suppress(fset *token.FileSet, findings []finding) []finding {
+	kept := findings[:0]
+	for _, f := range findings {
+		suppressed := false
+		for _, d := range ds {
+			if d.suppresses(fset, f.fieldPos) {
+				d.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// directiveProblem is a directive that is malformed, expired or doesn't suppress
+// anything, and what is wrong with it.
+type directiveProblem struct {
+	d       *directive
+	problem string
+}
+
+// problems returns the problems with the directives: being malformed, expired
+// or not suppressing anything.
+func (ds directives) problems() []directiveProblem {
+	var problems []directiveProblem
+	for _, d := range ds {
+		p := d.problem
+		switch {
+		case p != "":
+		case d.expired:
+			p = fmt.Sprintf("expired on %s", d.expires.Format("2006-01-02"))
+		case !d.used:
+			p = "unused directive"
+		default:
+			continue
+		}
+		problems = append(problems, directiveProblem{d, p})
+	}
+	return problems
+}
+
+// errors returns the problems with the directives as DirectiveErrors.
+func (ds directives) errors(fset *token.FileSet) []DirectiveError {
+	var errs []DirectiveError
+	for _, p := range ds.problems() {
+		errs = append(errs, DirectiveError{
+			Pos:     fset.Position(p.d.comment.Slash),
+			Text:    p.d.comment.Text,
+			Problem: p.problem,
+		})
+	}
+	return errs
+}
@@ -0,0 +1,10 @@
+package gettercheck
+
+// ResetAnalyzerConfigs forgets the configurations the analyzer loaded, for
+// tests changing the configuration file between runs.
+func ResetAnalyzerConfigs() {
+	analyzerConfigs.Range(func(key, _ interface{}) bool {
+		analyzerConfigs.Delete(key)
+		return true
+	})
+}
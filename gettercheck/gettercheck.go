@@ -5,15 +5,19 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"go/ast"
-	"go/format"
 	"go/token"
 	"go/types"
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/packages"
+	"os"
+	"reflect"
 	"regexp"
+	"runtime"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 var errorType *types.Interface
@@ -34,6 +38,43 @@ type UnusedGetterError struct {
 	GetterPos token.Position
 	Line      string
 	FuncName  string
+	// Reason explains why the getter can't be used as is, if it can't.
+	// Such errors aren't fixed when writing getters.
+	Reason string
+	// Builds lists the build contexts the error was found in, when packages
+	// were checked under more than one. Unique merges them.
+	Builds []string
+	// Fingerprint identifies the error regardless of its position.
+	Fingerprint Fingerprint
+	// End is the position right after the selector expression, which starts
+	// at Fingerprint.Selector and ends with the field at Pos.
+	End token.Position
+	// Receiver is the type of the expression the field is selected from.
+	Receiver string
+	// Field is the name of the field.
+	Field string
+}
+
+// message describes e in a single line.
+func (e UnusedGetterError) message() string {
+	return unusedGetterMessage(e.FuncName, e.Field, e.Reason)
+}
+
+// unusedGetterMessage describes selecting field instead of making the getter
+// call, which can't be made for reason if it is non-empty.
+func unusedGetterMessage(call, field, reason string) string {
+	message := fmt.Sprintf("unused getter: use %s instead of the %s field", call, field)
+	if reason != "" {
+		message += ": " + reason
+	}
+	return message
+}
+
+// sameError reports whether a and b are the same error, regardless of the
+// builds they were found in.
+func sameError(a, b UnusedGetterError) bool {
+	a.Builds, b.Builds = nil, nil
+	return reflect.DeepEqual(a, b)
 }
 
 // Result is returned from the CheckPackage function, and holds all the errors
@@ -45,6 +86,33 @@ type Result struct {
 	// UnusedGetterError is a list of all the unchecked errors in the package.
 	// Printing an error reports its position within the file and the contents of the line.
 	UnusedGetterError []UnusedGetterError
+
+	// ModifiedFiles lists the files that were rewritten to use getters.
+	ModifiedFiles []string
+
+	// Diffs holds the changes fixing the unused getters would make, if
+	// Checker.DiffGetters is set.
+	Diffs []FileDiff
+
+	// FileErrors holds the files that couldn't be fixed, and why.
+	FileErrors []FileError
+
+	// DirectiveErrors holds the suppression directives that are malformed,
+	// expired or unused, if Checker.UnusedDirectives is set.
+	DirectiveErrors []DirectiveError
+
+	// Packages lists the import paths of the packages that were checked.
+	Packages []string
+}
+
+// FileError indicates that fixing a file failed.
+type FileError struct {
+	Filename string
+	Err      error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Filename, e.Err)
 }
 
 type byName []UnusedGetterError
@@ -79,10 +147,16 @@ func (b byName) Len() int {
 // Append appends errors to e. Append does not do any duplicate checking.
 func (r *Result) Append(other Result) {
 	r.UnusedGetterError = append(r.UnusedGetterError, other.UnusedGetterError...)
+	r.ModifiedFiles = append(r.ModifiedFiles, other.ModifiedFiles...)
+	r.Diffs = append(r.Diffs, other.Diffs...)
+	r.FileErrors = append(r.FileErrors, other.FileErrors...)
+	r.DirectiveErrors = append(r.DirectiveErrors, other.DirectiveErrors...)
+	r.Packages = append(r.Packages, other.Packages...)
 }
 
 // Returns the unique errors that have been accumulated. Duplicates may occur
-// when a file containing an unchecked error belongs to > 1 package.
+// when a file containing an unchecked error belongs to > 1 package, or was
+// checked under more than one build context.
 //
 // The method receiver remains unmodified after the call to Unique.
 func (r Result) Unique() Result {
@@ -91,11 +165,65 @@ func (r Result) Unique() Result {
 	sort.Sort((byName)(result))
 	uniq := result[:0] // compact in-place
 	for i, err := range result {
-		if i == 0 || err != result[i-1] {
+		if i == 0 || !sameError(err, result[i-1]) {
 			uniq = append(uniq, err)
+			continue
 		}
+		last := &uniq[len(uniq)-1]
+		last.Builds = uniqueStrings(append(append([]string(nil), last.Builds...), err.Builds...))
+	}
+	diffs := make([]FileDiff, len(r.Diffs))
+	copy(diffs, r.Diffs)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Filename < diffs[j].Filename })
+	fileErrors := make([]FileError, len(r.FileErrors))
+	copy(fileErrors, r.FileErrors)
+	sort.SliceStable(fileErrors, func(i, j int) bool { return fileErrors[i].Filename < fileErrors[j].Filename })
+	return Result{
+		UnusedGetterError: uniq,
+		ModifiedFiles:     uniqueStrings(r.ModifiedFiles),
+		Diffs:             diffs,
+		FileErrors:        fileErrors,
+		DirectiveErrors:   uniqueDirectiveErrors(r.DirectiveErrors),
+		Packages:          uniqueStrings(r.Packages),
 	}
-	return Result{UnusedGetterError: uniq}
+}
+
+// uniqueDirectiveErrors returns the sorted, distinct elements of errs without
+// modifying it.
+func uniqueDirectiveErrors(errs []DirectiveError) []DirectiveError {
+	var result []DirectiveError
+	seen := make(map[DirectiveError]bool)
+	for _, err := range errs {
+		if !seen[err] {
+			seen[err] = true
+			result = append(result, err)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		pi, pj := result[i].Pos, result[j].Pos
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+	return result
+}
+
+// uniqueStrings returns the sorted, distinct elements of s without modifying it.
+func uniqueStrings(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	result := make([]string, len(s))
+	copy(result, s)
+	sort.Strings(result)
+	uniq := result[:0]
+	for i, str := range result {
+		if i == 0 || str != result[i-1] {
+			uniq = append(uniq, str)
+		}
+	}
+	return uniq
 }
 
 // Exclusions define symbols and language elements that will be not checked
@@ -112,6 +240,120 @@ type Exclusions struct {
 	//   ^// Code generated .* DO NOT EDIT\\.$
 	//
 	GeneratedFiles bool
+
+	// Ignore suppresses findings by message type, field and package.
+	Ignore Ignores
+}
+
+// BuildContext overrides the build configuration packages are loaded with.
+type BuildContext struct {
+	// Tags are build tags to consider satisfied, in addition to the default ones.
+	Tags []string
+
+	// GOOS, GOARCH and CGOEnabled override the environment variables of the
+	// same name if set. CGOEnabled must be "0" or "1".
+	GOOS       string
+	GOARCH     string
+	CGOEnabled string
+}
+
+// String formats b the way BuildContexts.Set parses it: an optional
+// goos/goarch pair, followed by a colon and the tags joined by "+" if any, or
+// "default" if neither is set.
+func (b BuildContext) String() string {
+	s := ""
+	if b.GOOS != "" || b.GOARCH != "" {
+		s = b.GOOS + "/" + b.GOARCH
+	}
+	if len(b.Tags) > 0 {
+		s += ":" + strings.Join(b.Tags, "+")
+	}
+	if s == "" {
+		return "default"
+	}
+	return s
+}
+
+// BuildContexts is a list of build contexts to check packages under. It
+// implements flag.Value, so it can be set from a comma separated list of
+// contexts formatted as by BuildContext.String, such as
+// "linux/amd64,linux/arm64:integration+e2e". Each use of the flag adds to the list.
+type BuildContexts []BuildContext
+
+func (b *BuildContexts) String() string {
+	contexts := make([]string, 0, len(*b))
+	for _, context := range *b {
+		contexts = append(contexts, context.String())
+	}
+	return strings.Join(contexts, ",")
+}
+
+func (b *BuildContexts) Set(s string) error {
+	for _, context := range strings.Split(s, ",") {
+		var build BuildContext
+		if context == "default" {
+			*b = append(*b, build)
+			continue
+		}
+		platform := context
+		if colonIndex := strings.Index(context, ":"); colonIndex != -1 {
+			platform = context[:colonIndex]
+			build.Tags = strings.Split(context[colonIndex+1:], "+")
+		}
+		if platform != "" {
+			slashIndex := strings.Index(platform, "/")
+			if slashIndex == -1 {
+				return fmt.Errorf("invalid build context %q, must be of the form goos/goarch[:tag+...]", context)
+			}
+			build.GOOS, build.GOARCH = platform[:slashIndex], platform[slashIndex+1:]
+		}
+		*b = append(*b, build)
+	}
+	return nil
+}
+
+// Override returns b with the settings of other that are set taking
+// precedence, and the tags of both.
+func (b BuildContext) Override(other BuildContext) BuildContext {
+	result := b
+	result.Tags = append(append([]string(nil), b.Tags...), other.Tags...)
+	if other.GOOS != "" {
+		result.GOOS = other.GOOS
+	}
+	if other.GOARCH != "" {
+		result.GOARCH = other.GOARCH
+	}
+	if other.CGOEnabled != "" {
+		result.CGOEnabled = other.CGOEnabled
+	}
+	return result
+}
+
+func (b BuildContext) buildFlags() []string {
+	if len(b.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(b.Tags, ",")}
+}
+
+// env returns the environment for the go command, or nil to use the current
+// process's unchanged.
+func (b BuildContext) env() []string {
+	var overrides []string
+	for _, v := range []struct{ name, value string }{
+		{"GOOS", b.GOOS},
+		{"GOARCH", b.GOARCH},
+		{"CGO_ENABLED", b.CGOEnabled},
+	} {
+		if v.value != "" {
+			overrides = append(overrides, v.name+"="+v.value)
+		}
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+	// Later entries take precedence.
+	return append(os.Environ(), overrides...)
 }
 
 // Checker checks that you checked errors.
@@ -121,8 +363,39 @@ type Checker struct {
 
 	WriteGetters bool
 
+	// DiffGetters computes the same fixes as WriteGetters, and reports them
+	// as unified diffs in Result.Diffs.
+	DiffGetters bool
+
+	// Profiles are the generator conventions used to recognise types with
+	// getters. If empty, DefaultProfiles is used.
+	Profiles Profiles
+
 	// The mod flag for go build.
 	Mod string
+
+	// Build selects the files that make up the packages being loaded.
+	Build BuildContext
+
+	// UnusedDirectives reports the suppression directives that are
+	// malformed, expired or don't suppress anything in
+	// Result.DirectiveErrors.
+	UnusedDirectives bool
+
+	// Overrides change the settings above for some directories.
+	Overrides []Override
+
+	// Overlay maps absolute file names to the contents packages are loaded
+	// with instead of those on disk, see packages.Config.Overlay.
+	Overlay map[string][]byte
+
+	// Baseline, if set, accepts the errors it records in each package, which
+	// are then neither fixed nor reported.
+	Baseline *Baseline
+
+	// ChangedLines, if set, restricts the errors that are fixed and reported
+	// to those on the changed lines.
+	ChangedLines ChangedLines
 }
 
 // loadPackages is used for testing.
@@ -131,20 +404,38 @@ var loadPackages = func(cfg *packages.Config, paths ...string) ([]*packages.Pack
 }
 
 // LoadPackages loads all the packages in all the paths provided. It uses the
-// exclusions and build tags provided to by the user when loading the packages.
+// exclusions, module mode and build context provided by the user when loading
+// the packages.
 func (c *Checker) LoadPackages(paths ...string) ([]*packages.Package, error) {
+	return loadPackages(c.packagesConfig(packages.LoadAllSyntax), paths...)
+}
+
+func (c *Checker) packagesConfig(mode packages.LoadMode) *packages.Config {
 	cfg := &packages.Config{
-		Mode:  packages.LoadAllSyntax,
+		Mode:  mode,
 		Tests: !c.Exclusions.TestFiles,
 	}
-	return loadPackages(cfg, paths...)
+	if c.Mod != "" {
+		cfg.BuildFlags = append(cfg.BuildFlags, "-mod="+c.Mod)
+	}
+	cfg.BuildFlags = append(cfg.BuildFlags, c.Build.buildFlags()...)
+	cfg.Env = c.Build.env()
+	cfg.Overlay = c.Overlay
+	return cfg
+}
+
+func (c *Checker) profiles() Profiles {
+	if len(c.Profiles) == 0 {
+		return DefaultProfiles()
+	}
+	return c.Profiles
 }
 
 var generatedCodeRegexp = regexp.MustCompile(`^//\s+Code generated.*DO NOT EDIT\.$`)
 var dotStar = regexp.MustCompile(".*")
 
-func (c *Checker) shouldSkipFile(file *ast.File) bool {
-	if !c.Exclusions.GeneratedFiles {
+func (s fileSettings) shouldSkipFile(file *ast.File) bool {
+	if !s.generatedFiles {
 		return false
 	}
 
@@ -159,11 +450,95 @@ func (c *Checker) shouldSkipFile(file *ast.File) bool {
 	return false
 }
 
+// CheckPackages checks pkgs concurrently, and returns the unique errors found
+// in them. A file belonging to more than one of the packages, such as a
+// package and its test variant, is only fixed once.
+func (c *Checker) CheckPackages(pkgs []*packages.Package) Result {
+	return c.check(pkgs).Unique()
+}
+
+// CheckMatrix loads and checks the packages in paths under each of the build
+// contexts in matrix, applied on top of c.Build, and merges the results. Each
+// error is annotated with the contexts it was found in. Files are fixed under
+// each context in turn, so those fixed under one are loaded fixed under the
+// next.
+func (c *Checker) CheckMatrix(matrix BuildContexts, paths ...string) (Result, error) {
+	result := Result{}
+	for _, build := range matrix {
+		checker := *c
+		checker.Build = c.Build.Override(build)
+		pkgs, err := checker.LoadPackages(paths...)
+		if err != nil {
+			return Result{}, fmt.Errorf("%s: %w", build, err)
+		}
+		for _, pkg := range pkgs {
+			if len(pkg.Errors) > 0 {
+				return Result{}, fmt.Errorf("%s: errors while loading package %s: %v", build, pkg.ID, pkg.Errors)
+			}
+		}
+		r := checker.CheckPackages(pkgs)
+		for i := range r.UnusedGetterError {
+			r.UnusedGetterError[i].Builds = []string{build.String()}
+		}
+		result.Append(r)
+	}
+	return result.Unique(), nil
+}
+
 // CheckPackage checks packages for errors that have not been checked.
 //
 // It will exclude specific errors from analysis if the user has configured
 // exclusions.
 func (c *Checker) CheckPackage(pkg *packages.Package) Result {
+	return c.check([]*packages.Package{pkg})
+}
+
+// checkedPackage holds the findings in a package until they are fixed.
+type checkedPackage struct {
+	pkg             *packages.Package
+	v               *visitor
+	files           []fileFindings
+	directiveErrors []DirectiveError
+}
+
+// check visits pkgs concurrently, and only fixes their findings once all of
+// them have been visited, so that each file is fixed once.
+func (c *Checker) check(pkgs []*packages.Package) Result {
+	checked := make([]*checkedPackage, len(pkgs))
+	work := make(chan int, len(pkgs))
+	for i := range pkgs {
+		work <- i
+	}
+	close(work)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				checked[i] = c.visitPackage(pkgs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	var result Result
+	if c.WriteGetters || c.DiffGetters {
+		for _, p := range checked {
+			p.v.readLines()
+		}
+		result.ModifiedFiles, result.Diffs, result.FileErrors = c.fix(checked)
+	}
+	for _, p := range checked {
+		result.UnusedGetterError = append(result.UnusedGetterError, p.v.unusedGetterErrors()...)
+		result.DirectiveErrors = append(result.DirectiveErrors, p.directiveErrors...)
+		result.Packages = append(result.Packages, p.pkg.PkgPath)
+	}
+	return result
+}
+
+func (c *Checker) visitPackage(pkg *packages.Package) *checkedPackage {
 
 	v := &visitor{
 		types:     pkg.Types,
@@ -171,29 +546,165 @@ func (c *Checker) CheckPackage(pkg *packages.Package) Result {
 		fset:      pkg.Fset,
 		imports:   pkg.Imports,
 		lines:     make(map[string][]string),
-		errors:    []UnusedGetterError{},
+		pkgPath:   pkg.PkgPath,
+	}
+	// The lines of overlaid files are reported as loaded, not as on disk.
+	for filename, src := range c.Overlay {
+		v.lines[filename] = scanLines(bytes.NewReader(src))
 	}
 
+	type fileRange struct {
+		filename   string
+		start, end int
+	}
+	var ranges []fileRange
+	checked := &checkedPackage{pkg: pkg, v: v}
+	accepted := c.Baseline.remaining()
+	now := time.Now()
 	for _, astFile := range pkg.Syntax {
-		if c.shouldSkipFile(astFile) {
+		settings := c.settings(v.fset.Position(astFile.Pos()).Filename)
+		if !settings.unusedGetter || settings.shouldSkipFile(astFile) {
 			continue
 		}
+		v.profiles, v.ignores = settings.profiles, settings.ignores
 
-		newFile := astutil.Apply(astFile, v.Visit, nil)
-		if c.WriteGetters {
-			buf := &bytes.Buffer{}
-			err := format.Node(buf, v.fset, newFile)
-			if err != nil {
-				panic(fmt.Errorf("error creating formatted code: %w", err))
+		found := len(v.findings)
+		astutil.Apply(astFile, v.Visit, nil)
+		directives := parseDirectives(v.fset, astFile, now)
+		v.findings = append(v.findings[:found], directives.suppress(v.fset, v.findings[found:])...)
+		if settings.unusedDirective {
+			for _, err := range directives.errors(v.fset) {
+				if c.ChangedLines != nil && !c.ChangedLines.Contains(err.Pos.Filename, err.Pos.Line) {
+					continue
+				}
+				err.Package = pkg.PkgPath
+				checked.directiveErrors = append(checked.directiveErrors, err)
 			}
-			fileName := v.fset.Position(astFile.Pos()).Filename
-			err = ioutil.WriteFile(fileName, buf.Bytes(), 0644)
-			if err != nil {
-				panic(err)
+		}
+		v.findings = append(v.findings[:found], c.keep(v, accepted, v.findings[found:])...)
+		if len(v.findings) > found {
+			ranges = append(ranges, fileRange{v.fset.Position(astFile.Pos()).Filename, found, len(v.findings)})
+		}
+	}
+
+	// The findings are only sliced up once they have all been collected,
+	// so that rejecting a fix is reflected in v.findings.
+	for _, r := range ranges {
+		checked.files = append(checked.files, fileFindings{r.filename, v.findings[r.start:r.end]})
+	}
+	return checked
+}
+
+// keep returns the findings that aren't accepted by the baseline and are on
+// the changed lines, given the number of errors the baseline still accepts by
+// fingerprint, which it decrements.
+func (c *Checker) keep(v *visitor, accepted map[Fingerprint]int, findings []finding) []finding {
+	if c.Baseline == nil && c.ChangedLines == nil {
+		return findings
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		err := v.unusedGetterError(f)
+		if accepted[err.Fingerprint] > 0 {
+			accepted[err.Fingerprint]--
+			continue
+		}
+		if c.ChangedLines != nil && !c.ChangedLines.Contains(err.Pos.Filename, err.Pos.Line) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// fix applies the fixes for the findings of the checked packages, and returns
+// the files it wrote, the diffs it computed and the files it failed to fix.
+//
+// A file belonging to several packages, such as a package and its test
+// variant, is fixed along with the package with the most findings in it.
+// Fixes that would break that package are rejected beforehand, and the
+// rejections are shared with the file's findings in the other packages.
+func (c *Checker) fix(checked []*checkedPackage) (modified []string, diffs []FileDiff, fileErrors []FileError) {
+	type owner struct {
+		p    *checkedPackage
+		file fileFindings
+	}
+	owners := make(map[string]owner)
+	var filenames []string
+	for _, p := range checked {
+		for _, f := range p.files {
+			o, ok := owners[f.filename]
+			if !ok {
+				filenames = append(filenames, f.filename)
+			}
+			if !ok || len(f.findings) > len(o.file.findings) {
+				owners[f.filename] = owner{p, f}
 			}
 		}
 	}
-	return Result{
-		UnusedGetterError: v.errors,
+	sort.Strings(filenames)
+
+	// Only files with at least one fix are written, leaving the
+	// formatting of the others untouched, and only packages owning such
+	// files are type-checked again.
+	for _, p := range checked {
+		var fixable []fileFindings
+		for _, filename := range filenames {
+			if o := owners[filename]; o.p == p && len(o.file.edits()) > 0 {
+				fixable = append(fixable, o.file)
+			}
+		}
+		if len(fixable) > 0 {
+			c.rejectBrokenFixes(p.pkg, fixable)
+		}
+	}
+
+	type key struct {
+		filename string
+		offset   int
+	}
+	reasons := make(map[key]string)
+	for _, filename := range filenames {
+		o := owners[filename]
+		for _, f := range o.file.findings {
+			reasons[key{filename, o.p.pkg.Fset.Position(f.fieldPos).Offset}] = f.reason
+		}
+	}
+	for _, p := range checked {
+		for _, file := range p.files {
+			if owners[file.filename].p == p {
+				continue
+			}
+			for i, f := range file.findings {
+				if reason, ok := reasons[key{file.filename, p.pkg.Fset.Position(f.fieldPos).Offset}]; ok {
+					file.findings[i].reason = reason
+				}
+			}
+		}
+	}
+
+	for _, filename := range filenames {
+		o := owners[filename]
+		edits := o.file.edits()
+		if len(edits) == 0 {
+			continue
+		}
+		src, fixed, err := fixFile(o.p.pkg.Fset, filename, edits)
+		if err != nil {
+			fileErrors = append(fileErrors, FileError{filename, err})
+			continue
+		}
+		if c.DiffGetters {
+			diffs = append(diffs, FileDiff{Filename: filename, Hunks: unifiedDiff(src, fixed)})
+		}
+		if c.WriteGetters {
+			err = writeFile(filename, fixed)
+			if err != nil {
+				fileErrors = append(fileErrors, FileError{filename, err})
+				continue
+			}
+			modified = append(modified, filename)
+		}
 	}
+	return modified, diffs, fileErrors
 }
@@ -6,7 +6,7 @@ import (
 	"testing"
 )
 
-func Testgettercheck(t *testing.T) {
+func TestGettercheck(t *testing.T) {
 	gomega.RegisterFailHandler(ginkgo.Fail)
 	ginkgo.RunSpecs(t, "gettercheck suite test")
 }
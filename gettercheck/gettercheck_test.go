@@ -1,17 +1,28 @@
 package gettercheck_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/saiskee/gettercheck/gettercheck"
+	"go/token"
+	"golang.org/x/tools/go/analysis"
 	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 )
 
 const testPackage = "github.com/saiskee/gettercheck/gettercheck/testdata/src"
 
-var _ = FDescribe("gettercheck Suite Test", func() {
+var _ = Describe("gettercheck Suite Test", func() {
 	var (
 		checker *gettercheck.Checker
 	)
@@ -32,7 +43,30 @@ var _ = FDescribe("gettercheck Suite Test", func() {
 		}
 	}
 
+	RunAnalyzer := func() ([]analysis.Diagnostic, *token.FileSet) {
+		pkgs, err := checker.LoadPackages(testPackage)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		ExpectWithOffset(1, pkgs).To(HaveLen(1))
+		pkg := pkgs[0]
+		var diagnostics []analysis.Diagnostic
+		pass := &analysis.Pass{
+			Analyzer:   gettercheck.Analyzer,
+			Fset:       pkg.Fset,
+			Files:      pkg.Syntax,
+			Pkg:        pkg.Types,
+			TypesInfo:  pkg.TypesInfo,
+			TypesSizes: pkg.TypesSizes,
+			Report: func(d analysis.Diagnostic) {
+				diagnostics = append(diagnostics, d)
+			},
+		}
+		_, err = gettercheck.Analyzer.Run(pass)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		return diagnostics, pkg.Fset
+	}
+
 	BeforeEach(func(){
+		gettercheck.ResetAnalyzerConfigs()
 		checker = &gettercheck.Checker{
 			Exclusions: gettercheck.Exclusions{
 				GeneratedFiles: true,
@@ -94,6 +128,29 @@ var _ = FDescribe("gettercheck Suite Test", func() {
 
 	})
 
+	It("reports selectors in keys, values and operands of unary expressions once", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = map[string]*Basic{p.Child.Name: p.Child}
+_, _ = &p.Child.Name, !(p.Child == nil)`)
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:31",
+		}, UnusedGetterExpectation{
+			ExpectedGetter:  "GetChild()",
+			ExpectedLinePos: "10:25",
+		}, UnusedGetterExpectation{
+			ExpectedGetter:  "GetChild()",
+			ExpectedLinePos: "10:39",
+		}, UnusedGetterExpectation{
+			ExpectedGetter:  "GetChild()",
+			ExpectedLinePos: "11:11",
+		}, UnusedGetterExpectation{
+			ExpectedGetter:  "GetChild()",
+			ExpectedLinePos: "11:27",
+		})
+	})
+
 	It("throws error when chained getters are needed", func(){
 		WriteTestFileBoostrap(`
 g := GrandParent{Child: &Parent{Child: &Basic{}}}
@@ -139,7 +196,7 @@ address := "Muffin Man Lane"
 		ExpectUnusedGetterResult()
 	})
 
-	FIt("finds errors inside parentheses", func(){
+	It("finds errors inside parentheses", func(){
 		WriteTestFileBoostrap(`
 p := Parent{Child: &Basic{Name: "hello"}}
 _ = ((p.Child.Name))`)
@@ -155,6 +212,1307 @@ _ = ((p.Child.Name))`)
 
 	})
 
+	It("finds unused getters on messages generated into files without a .pb.go suffix", func(){
+		WriteTestFileBoostrap(`
+r := &Renamed{}
+_ = r.Name`)
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:7",
+		})
+	})
+
+	It("finds unused getters on gogo/protobuf messages with the gogo profile", func(){
+		Expect(checker.Profiles.Set("gogo")).To(Succeed())
+		WriteTestFileBoostrap(`
+r, n := &Renamed{}, &NotAMessage{}
+_, _ = r.Name, n.Name`)
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:10",
+		})
+	})
+
+	It("doesn't show error for getters on types that aren't messages", func(){
+		WriteTestFileBoostrap(`
+n := &NotAMessage{}
+_ = n.Name`)
+		ExpectUnusedGetterResult()
+	})
+
+	It("finds unused getters on hand-written types with the custom profile", func(){
+		Expect(checker.Profiles.Set("protobuf,custom")).To(Succeed())
+		WriteTestFileBoostrap(`
+n := &NotAMessage{}
+_ = n.Name`)
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:7",
+		})
+	})
+
+	It("doesn't replace the field read by the getter itself", func(){
+		Expect(checker.Profiles.Set("custom")).To(Succeed())
+		checker.WriteGetters = true
+		WriteMain(`package src
+
+type User struct {
+	Name string
+}
+
+func (u *User) GetName() string {
+	if u == nil {
+		return ""
+	}
+	return u.Name
+}
+
+func main() {
+	u := &User{}
+	_ = u.Name
+}`)
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "16:8",
+		})
+		Expect(ReadMain()).To(ContainSubstring("return u.Name\n"))
+		Expect(ReadMain()).To(ContainSubstring("_ = u.GetName()\n"))
+	})
+
+	It("tells thrift structs and protobuf messages apart", func(){
+		WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+	"github.com/saiskee/gettercheck/gettercheck/testdata/src/thriftgen"
+)
+
+func main() {
+	u, b := &thriftgen.User{}, &Basic{}
+	_, _, _ = u.Name, u.Email, b.Name
+}`)
+		Expect(checker.Profiles.Set("thrift")).To(Succeed())
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:14",
+		}, UnusedGetterExpectation{
+			ExpectedGetter:  "GetEmail()",
+			ExpectedLinePos: "10:22",
+		})
+		Expect(checker.Profiles.Set("protobuf")).To(Succeed())
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:31",
+		})
+	})
+
+	It("rejects unknown profiles", func(){
+		Expect(checker.Profiles.Set("protobuf,corba")).To(MatchError(ContainSubstring(`unknown profile "corba"`)))
+	})
+
+	It("suggests fixes from the analyzer", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child.Name`)
+		diagnostics, fset := RunAnalyzer()
+		Expect(diagnostics).To(HaveLen(2))
+		var edits []analysis.TextEdit
+		for _, d := range diagnostics {
+			Expect(d.SuggestedFixes).To(HaveLen(1))
+			edits = append(edits, d.SuggestedFixes[0].TextEdits...)
+		}
+		Expect(ApplyEdits(fset, edits)).To(ContainSubstring("_ = p.GetChild().GetName()"))
+	})
+
+	It("reports the range of the selector and the getter declaration from the analyzer", func(){
+		WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+		diagnostics, fset := RunAnalyzer()
+		Expect(diagnostics).To(HaveLen(1))
+		d := diagnostics[0]
+		Expect(d.Message).To(Equal("unused getter: use GetName() instead of the Name field"))
+		start, end := fset.Position(d.Pos), fset.Position(d.End)
+		Expect(fmt.Sprintf("%d:%d-%d:%d", start.Line, start.Column, end.Line, end.Column)).To(Equal("10:5-10:11"))
+		Expect(d.Related).To(HaveLen(1))
+		getter := fset.Position(d.Related[0].Pos)
+		Expect(getter.Filename).To(HaveSuffix("A.pb.go"))
+		Expect(fmt.Sprintf("%d:%d", getter.Line, getter.Column)).To(Equal("10:17"))
+	})
+
+	Context("when writing getters", func(){
+		BeforeEach(func(){
+			checker.WriteGetters = true
+		})
+
+		It("rewrites files with findings and preserves their mode", func(){
+			WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+			Expect(os.Chmod("testdata/src/main.go", 0600)).To(Succeed())
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			r := checker.CheckPackage(pkgs[0])
+			Expect(r.ModifiedFiles).To(ConsistOf(HaveSuffix("testdata/src/main.go")))
+			contents, err := ioutil.ReadFile("testdata/src/main.go")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("_ = b.GetName()"))
+			info, err := os.Stat("testdata/src/main.go")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("reports the lines of findings as they were before fixing", func(){
+			WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			r := checker.CheckPackage(pkgs[0])
+			Expect(r.UnusedGetterError).To(HaveLen(1))
+			Expect(r.UnusedGetterError[0].Line).To(Equal("_ = b.Name"))
+			Expect(ReadMain()).To(ContainSubstring("_ = b.GetName()"))
+		})
+
+		It("fixes the same file again when checking it again", func(){
+			for i := 0; i < 2; i++ {
+				WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+				pkgs, err := checker.LoadPackages(testPackage)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checker.CheckPackages(pkgs).ModifiedFiles).To(HaveLen(1))
+				Expect(ReadMain()).To(ContainSubstring("_ = b.GetName()"))
+			}
+		})
+
+		It("only changes the fixed selectors", func(){
+			WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child.Name   // keep   this comment
+x :=   1
+_ = x`)
+			expected := strings.Replace(ReadMain(), "p.Child.Name", "p.GetChild().GetName()", 1)
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			checker.CheckPackage(pkgs[0])
+			Expect(ReadMain()).To(Equal(expected))
+		})
+
+		It("rejects fixes that don't type-check", func(){
+			WriteTestFileBoostrap(`
+o := &Optional{}
+var s *string = o.Value
+_, _ = s, o.Value`)
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			r := checker.CheckPackage(pkgs[0])
+			Expect(r.UnusedGetterError).To(HaveLen(2))
+			Expect(r.UnusedGetterError[0].Reason).To(HavePrefix("fix rejected: cannot use o.GetValue()"))
+			Expect(r.UnusedGetterError[1].Reason).To(BeEmpty())
+			Expect(ReadMain()).To(ContainSubstring("var s *string = o.Value\n_, _ = s, o.GetValue()"))
+		})
+
+		It("rejects fixes in files shared with the test variant once", func(){
+			WriteTestFileBoostrap(`
+o := &Optional{}
+var s *string = o.Value
+_, _ = s, o.Value`)
+			Expect(ioutil.WriteFile("testdata/src/main_test.go", []byte("package src\n\nimport \"testing\"\n\nfunc TestMain(t *testing.T) {}\n"), 0644)).To(Succeed())
+			defer os.Remove("testdata/src/main_test.go")
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(pkgs)).To(BeNumerically(">", 1))
+			r := checker.CheckPackages(pkgs)
+			Expect(r.UnusedGetterError).To(HaveLen(2))
+			Expect(r.UnusedGetterError[0].Reason).To(HavePrefix("fix rejected: cannot use o.GetValue()"))
+			Expect(r.UnusedGetterError[1].Reason).To(BeEmpty())
+			Expect(r.ModifiedFiles).To(ConsistOf(HaveSuffix("testdata/src/main.go")))
+			Expect(ReadMain()).To(ContainSubstring("var s *string = o.Value\n_, _ = s, o.GetValue()"))
+		})
+
+		It("reports the files it fails to write and leaves them untouched", func(){
+			if os.Geteuid() == 0 {
+				Skip("root can write to read-only directories")
+			}
+			WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+			original := ReadMain()
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Chmod("testdata/src", 0555)).To(Succeed())
+			defer os.Chmod("testdata/src", 0755)
+			r := checker.CheckPackage(pkgs[0])
+			Expect(r.UnusedGetterError).To(HaveLen(1))
+			Expect(r.ModifiedFiles).To(BeEmpty())
+			Expect(r.FileErrors).To(HaveLen(1))
+			Expect(r.FileErrors[0].Filename).To(HaveSuffix("testdata/src/main.go"))
+			Expect(os.IsPermission(r.FileErrors[0].Err)).To(BeTrue())
+			Expect(ReadMain()).To(Equal(original))
+		})
+
+		It("doesn't fix files that changed since they were loaded", func(){
+			WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			WriteMain(ReadMain() + "\n// changed")
+			r := checker.CheckPackage(pkgs[0])
+			Expect(r.UnusedGetterError).To(HaveLen(1))
+			Expect(r.UnusedGetterError[0].Reason).To(Equal("fix rejected: file changed since it was loaded"))
+			Expect(r.ModifiedFiles).To(BeEmpty())
+			Expect(ReadMain()).To(HaveSuffix("// changed"))
+		})
+
+		It("doesn't touch files without findings", func(){
+			WriteTestFileBoostrap(`
+b :=   &Basic{}
+_ = b.GetName()`)
+			before, err := ioutil.ReadFile("testdata/src/main.go")
+			Expect(err).NotTo(HaveOccurred())
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			r := checker.CheckPackage(pkgs[0])
+			Expect(r.ModifiedFiles).To(BeEmpty())
+			after, err := ioutil.ReadFile("testdata/src/main.go")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(after).To(Equal(before))
+		})
+	})
+
+	It("reports the fixes as a unified diff without writing them", func(){
+		checker.DiffGetters = true
+		WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+		before := ReadMain()
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		Expect(r.Diffs).To(HaveLen(1))
+		Expect(r.Diffs[0].Hunks).To(Equal(`@@ -7,5 +7,5 @@
+ func main() {
+ 
+ b := &Basic{}
+-_ = b.Name
++_ = b.GetName()
+ }
+\ No newline at end of file
+`))
+		Expect(ReadMain()).To(Equal(before))
+	})
+
+	It("doesn't show error when taking the address of a parenthesized field or assigning to it", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = &(p.Child)
+(p.Child) = nil`)
+		ExpectUnusedGetterResult()
+	})
+
+	It("explains why getters can't be called on values that aren't addressable", func(){
+		WriteTestFileBoostrap(`
+f := func() Basic { return Basic{} }
+_ = f().Name`)
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:9",
+		})
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		Expect(r.UnusedGetterError[0].Reason).To(Equal("GetName has a pointer receiver and f() is not addressable"))
+
+		diagnostics, _ := RunAnalyzer()
+		Expect(diagnostics).To(HaveLen(1))
+		Expect(diagnostics[0].SuggestedFixes).To(BeEmpty())
+		Expect(diagnostics[0].Message).To(Equal("unused getter: use GetName() instead of the Name field: GetName has a pointer receiver and f() is not addressable"))
+	})
+
+	It("fixes fields of addressable values", func(){
+		WriteTestFileBoostrap(`
+bs := []Basic{{}}
+_ = bs[0].Name`)
+		diagnostics, _ := RunAnalyzer()
+		Expect(diagnostics).To(HaveLen(1))
+		Expect(diagnostics[0].SuggestedFixes).To(HaveLen(1))
+	})
+
+	It("ignores fields matching the ignore rules", func(){
+		Expect(checker.Exclusions.Ignore.Set(`^Parent\.,\.Address$`)).To(Succeed())
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_, _, _ = p.Child.Name, p.Child.Address, p.Child`)
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:19",
+		})
+	})
+
+	It("only ignores fields in packages matching the ignore rules", func(){
+		Expect(checker.Exclusions.Ignore.Set(`example.com/other:Basic\.Name`)).To(Succeed())
+		WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+		ExpectUnusedGetterResult(UnusedGetterExpectation{
+			ExpectedGetter:  "GetName()",
+			ExpectedLinePos: "10:7",
+		})
+		checker.Exclusions.Ignore = nil
+		Expect(checker.Exclusions.Ignore.Set(`gettercheck/testdata/src$:Basic\.Name`)).To(Succeed())
+		ExpectUnusedGetterResult()
+	})
+
+	Context("with promoted fields", func(){
+		CheckMain := func() []gettercheck.UnusedGetterError {
+			pkgs, err := checker.LoadPackages(testPackage)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+			return checker.CheckPackage(pkgs[0]).UnusedGetterError
+		}
+
+		It("calls getters promoted like the fields are", func(){
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+type Wrapper struct{ *Basic }
+
+type Outer struct{ Wrapper }
+
+type ByValue struct{ Basic }
+
+func main() {
+	w := Wrapper{Basic: &Basic{}}
+	o := &Outer{}
+	f := func() ByValue { return ByValue{} }
+	_, _, _ = w.Name, o.Name, f().Name
+}`)
+			errs := CheckMain()
+			Expect(errs).To(HaveLen(3))
+			for _, err := range errs {
+				Expect(err.FuncName).To(Equal("GetName()"))
+			}
+			Expect(errs[0].Reason).To(BeEmpty())
+			Expect(errs[1].Reason).To(BeEmpty())
+			Expect(errs[2].Reason).To(Equal("GetName has a pointer receiver and f() is not addressable"))
+			Expect(errs[0].Receiver).To(Equal("github.com/saiskee/gettercheck/gettercheck/testdata/src.Wrapper"))
+
+			checker.WriteGetters = true
+			CheckMain()
+			Expect(ReadMain()).To(ContainSubstring("_, _, _ = w.GetName(), o.GetName(), f().Name"))
+		})
+
+		It("selects the embedded field when the getter is shadowed or ambiguous", func(){
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+type Shadowed struct{ *Basic }
+
+func (Shadowed) GetName() string { return "" }
+
+type Namer struct{}
+
+func (Namer) GetName() string { return "" }
+
+type Ambiguous struct {
+	*Basic
+	Namer
+}
+
+type Nested struct{ Ambiguous }
+
+func main() {
+	s, a, n := Shadowed{}, Ambiguous{}, Nested{}
+	_, _, _ = s.Name, a.Name, n.Name
+}`)
+			errs := CheckMain()
+			Expect(errs).To(HaveLen(3))
+			Expect(errs[0].FuncName).To(Equal("Basic.GetName()"))
+			Expect(errs[1].FuncName).To(Equal("Basic.GetName()"))
+			Expect(errs[2].FuncName).To(Equal("Ambiguous.Basic.GetName()"))
+			for _, err := range errs {
+				Expect(err.Reason).To(BeEmpty())
+			}
+
+			checker.WriteGetters = true
+			CheckMain()
+			Expect(ReadMain()).To(ContainSubstring("_, _, _ = s.Basic.GetName(), a.Basic.GetName(), n.Ambiguous.Basic.GetName()"))
+		})
+
+		It("selects the fewest embedded fields the getter is promoted through", func(){
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+type Wrapper struct{ *Basic }
+
+type Namer struct{}
+
+func (Namer) GetName() string { return "" }
+
+type Outer struct {
+	Wrapper
+	Namer
+}
+
+func main() {
+	o := Outer{}
+	_ = o.Name
+}`)
+			errs := CheckMain()
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].FuncName).To(Equal("Wrapper.GetName()"))
+			Expect(errs[0].Reason).To(BeEmpty())
+
+			checker.WriteGetters = true
+			CheckMain()
+			Expect(ReadMain()).To(ContainSubstring("_ = o.Wrapper.GetName()"))
+		})
+
+		It("doesn't fix getters behind unexported embedded fields of other packages", func(){
+			WriteMain(`package src
+
+import (
+	"github.com/saiskee/gettercheck/gettercheck/testdata/src/embedding"
+)
+
+func main() {
+	p := embedding.Public{}
+	_ = p.Name
+}`)
+			errs := CheckMain()
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].FuncName).To(Equal("basic.GetName()"))
+			Expect(errs[0].Reason).To(Equal("the embedded field basic is not exported"))
+
+			checker.WriteGetters = true
+			CheckMain()
+			Expect(ReadMain()).To(ContainSubstring("_ = p.Name"))
+		})
+
+		It("selects unexported embedded fields of the package being analyzed", func(){
+			WriteMain(`package src
+
+import (
+	"github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+type basic = generated.Basic
+
+type W struct{ *basic }
+
+func (W) GetName() string { return "" }
+
+func main() {
+	w := W{}
+	_ = w.Name
+}`)
+			diagnostics, fset := RunAnalyzer()
+			Expect(diagnostics).To(HaveLen(1))
+			Expect(diagnostics[0].Message).To(Equal("unused getter: use basic.GetName() instead of the Name field"))
+			Expect(diagnostics[0].SuggestedFixes).To(HaveLen(1))
+			Expect(ApplyEdits(fset, diagnostics[0].SuggestedFixes[0].TextEdits)).To(ContainSubstring("_ = w.basic.GetName()"))
+		})
+
+		It("checks the profile and ignore rules of the type declaring the field", func(){
+			Expect(checker.Exclusions.Ignore.Set(`^Basic\.Name$`)).To(Succeed())
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+type Wrapper struct {
+	*Basic
+	*Parent
+}
+
+func main() {
+	w := Wrapper{}
+	_, _ = w.Name, w.Child
+}`)
+			errs := CheckMain()
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].FuncName).To(Equal("GetChild()"))
+		})
+	})
+
+	Context("with type parameters", func(){
+		It("finds unused getters of instantiated generic types", func(){
+			checker.Profiles = nil
+			Expect(checker.Profiles.Set("protobuf,custom")).To(Succeed())
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+type Box[T any] struct {
+	Value T
+}
+
+func (b *Box[T]) GetValue() T {
+	var zero T
+	return zero
+}
+
+type Repo[M interface{ *Basic }] struct {
+	*Parent
+	items []M
+}
+
+func main() {
+	b, r := &Box[int]{}, Repo[*Basic]{}
+	_, _, _ = b.Value, r.Child, r.items[0].Name
+}`)
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			errs := checker.CheckPackage(pkgs[0]).UnusedGetterError
+			Expect(errs).To(HaveLen(3))
+			Expect(errs[0].FuncName).To(Equal("GetValue()"))
+			Expect(errs[1].FuncName).To(Equal("GetChild()"))
+			Expect(errs[2].FuncName).To(Equal("GetName()"))
+			for _, err := range errs {
+				Expect(err.Reason).To(BeEmpty())
+			}
+		})
+
+		It("fixes fields of elements of type parameters with slice core types", func(){
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+func first[S ~[]Basic](s S) string {
+	return s[0].Name
+}
+
+func ids[T interface{ *Basic }](xs []T) {
+	for _, x := range xs {
+		_ = (*Basic)(x).Name
+	}
+}
+
+func main() {
+	_ = first([]Basic{{}})
+}`)
+			diagnostics, _ := RunAnalyzer()
+			Expect(diagnostics).To(HaveLen(2))
+			for _, diagnostic := range diagnostics {
+				Expect(diagnostic.SuggestedFixes).To(HaveLen(1))
+			}
+		})
+
+		It("intersects the type sets of constraints with several elements", func(){
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+func first[S interface {
+	~[]Basic
+	~[]Basic | ~[]Parent
+}](s S) string {
+	return s[0].Name
+}
+
+func main() {
+	_ = first([]Basic{{}})
+}`)
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			errs := checker.CheckPackage(pkgs[0]).UnusedGetterError
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Reason).To(BeEmpty())
+
+			checker.WriteGetters = true
+			checker.CheckPackage(pkgs[0])
+			Expect(ReadMain()).To(ContainSubstring("return s[0].GetName()"))
+		})
+
+		It("doesn't tell whether elements of type parameters without a core type are addressable", func(){
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+func first[S ~[]Basic | ~[2]Basic](s S) string {
+	return s[0].Name
+}
+
+func main() {
+	_ = first([]Basic{{}})
+}`)
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			errs := checker.CheckPackage(pkgs[0]).UnusedGetterError
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Reason).To(Equal("GetName has a pointer receiver and the core type of S is not determined"))
+		})
+	})
+
+	Context("with suppression directives", func(){
+		It("suppresses findings on the directive's line and in the statement it precedes", func(){
+			WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child //gettercheck:ignore legacy API
+//gettercheck:ignore migrated in a follow-up
+_ = []string{
+	p.Child.Name,
+	p.Child.Name,
+}
+_ = p.Child`)
+			ExpectUnusedGetterResult(UnusedGetterExpectation{
+				ExpectedGetter:  "GetChild()",
+				ExpectedLinePos: "16:7",
+			})
+		})
+
+		It("doesn't extend a trailing directive to the next statement", func(){
+			WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child //gettercheck:ignore legacy API
+_ = p.Child`)
+			ExpectUnusedGetterResult(UnusedGetterExpectation{
+				ExpectedGetter:  "GetChild()",
+				ExpectedLinePos: "11:7",
+			})
+		})
+
+		It("suppresses findings in the function it documents, or in the whole file", func(){
+			WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+// legacy reads fields directly.
+//gettercheck:ignore kept for compatibility
+func legacy(b *Basic) {
+	_ = b.Name
+}
+
+func main() {
+	_ = (&Basic{}).Name
+}`)
+			ExpectUnusedGetterResult(UnusedGetterExpectation{
+				ExpectedGetter:  "GetName()",
+				ExpectedLinePos: "14:17",
+			})
+			WriteTestFileBoostrap(`
+//gettercheck:file-ignore generated by hand
+_ = (&Basic{}).Name`)
+			ExpectUnusedGetterResult()
+		})
+
+		It("reports malformed, expired and unused directives", func(){
+			checker.UnusedDirectives = true
+			WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name //gettercheck:ignore expires=2000-01-01 until the migration
+_ = b.Name //gettercheck:ignore expires=2999-01-01 until the migration
+_ = b.Name //gettercheck:ignore
+_ = b //gettercheck:ignore nothing to suppress`)
+			pkgs, err := checker.LoadPackages(testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			r := checker.CheckPackage(pkgs[0])
+			Expect(r.UnusedGetterError).To(HaveLen(2))
+			Expect(r.UnusedGetterError[0].Pos.Line).To(Equal(10))
+			Expect(r.UnusedGetterError[1].Pos.Line).To(Equal(12))
+			Expect(r.DirectiveErrors).To(HaveLen(3))
+			Expect(r.DirectiveErrors[0].Pos.Line).To(Equal(10))
+			Expect(r.DirectiveErrors[0].Problem).To(Equal("expired on 2000-01-01"))
+			Expect(r.DirectiveErrors[1].Pos.Line).To(Equal(12))
+			Expect(r.DirectiveErrors[1].Problem).To(Equal("missing reason"))
+			Expect(r.DirectiveErrors[2].Pos.Line).To(Equal(13))
+			Expect(r.DirectiveErrors[2].Problem).To(Equal("unused directive"))
+		})
+
+		It("is honoured by the analyzer", func(){
+			WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child //gettercheck:ignore legacy API
+_ = p.Child`)
+			diagnostics, fset := RunAnalyzer()
+			Expect(diagnostics).To(HaveLen(1))
+			Expect(fset.Position(diagnostics[0].Pos).Line).To(Equal(11))
+		})
+
+		It("is reported by the analyzer at the directive", func(){
+			Expect(gettercheck.Analyzer.Flags.Set("unused-directives", "true")).To(Succeed())
+			defer gettercheck.Analyzer.Flags.Set("unused-directives", "false")
+			WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b //gettercheck:ignore nothing to suppress`)
+			diagnostics, fset := RunAnalyzer()
+			Expect(diagnostics).To(HaveLen(1))
+			Expect(diagnostics[0].Message).To(Equal("//gettercheck:ignore nothing to suppress: unused directive"))
+			Expect(fset.Position(diagnostics[0].Pos).String()).To(HaveSuffix("main.go:10:7"))
+		})
+	})
+
+	It("only reports findings missing from the baseline", func(){
+		CheckMain := func() gettercheck.Result {
+			pkgs, err := checker.LoadPackages(testPackage)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+			return checker.CheckPackage(pkgs[0])
+		}
+		WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+func (p *Parent) child() *Basic {
+	return p.Child
+}
+
+func main() {
+	b := &Basic{}
+	_ = b.Name
+}`)
+		r := CheckMain()
+		Expect(r.UnusedGetterError).To(HaveLen(2))
+		Expect(r.UnusedGetterError[0].Fingerprint).To(Equal(gettercheck.Fingerprint{
+			Package:  "github.com/saiskee/gettercheck/gettercheck/testdata/src",
+			Function: "(*Parent).child",
+			Selector: "p.Child",
+		}))
+		Expect(r.UnusedGetterError[1].Fingerprint.Function).To(Equal("main"))
+
+		dir, err := ioutil.TempDir("", "gettercheck")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		filename := dir + "/baseline.json"
+		Expect(gettercheck.NewBaseline(r.UnusedGetterError).WriteFile(filename)).To(Succeed())
+		baseline, err := gettercheck.ReadBaseline(filename)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Moving the known findings around doesn't report them, but
+		// accessing the same field once more does.
+		WriteMain(`package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+func main() {
+	b := &Basic{}
+
+	_ = b.Name
+	_ = b.Name
+}
+
+func (p *Parent) child() *Basic {
+	return p.Child
+}`)
+		errs := baseline.Filter(CheckMain()).UnusedGetterError
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Pos.Line).To(Equal(11))
+	})
+
+	It("neither fixes nor diffs findings accepted by the baseline", func(){
+		WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		checker.Baseline = gettercheck.NewBaseline(checker.CheckPackage(pkgs[0]).UnusedGetterError)
+
+		WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name
+_ = b.Name`)
+		checker.WriteGetters, checker.DiffGetters = true, true
+		pkgs, err = checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		Expect(r.UnusedGetterError).To(HaveLen(1))
+		Expect(r.UnusedGetterError[0].Pos.Line).To(Equal(11))
+		Expect(r.Diffs).To(HaveLen(1))
+		Expect(r.Diffs[0].Hunks).To(ContainSubstring("-_ = b.Name\n+_ = b.GetName()\n"))
+		Expect(strings.Count(r.Diffs[0].Hunks, "\n+")).To(Equal(1))
+		Expect(ReadMain()).To(ContainSubstring("_ = b.Name\n_ = b.GetName()"))
+	})
+
+	It("writes findings as JSON", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child`)
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		var buf bytes.Buffer
+		Expect(gettercheck.WriteJSON(&buf, r)).To(Succeed())
+		var report gettercheck.JSONReport
+		Expect(json.Unmarshal(buf.Bytes(), &report)).To(Succeed())
+		Expect(report.Version).To(Equal(gettercheck.JSONSchemaVersion))
+		Expect(report.Findings).To(HaveLen(1))
+		finding := report.Findings[0]
+		Expect(finding.File).To(HaveSuffix("testdata/src/main.go"))
+		Expect([]int{finding.Line, finding.Column, finding.EndLine, finding.EndColumn}).To(Equal([]int{10, 7, 10, 12}))
+		Expect(finding.Package).To(Equal("github.com/saiskee/gettercheck/gettercheck/testdata/src"))
+		Expect(finding.Receiver).To(Equal("*github.com/saiskee/gettercheck/gettercheck/testdata/src/generated.Parent"))
+		Expect(finding.Field).To(Equal("Child"))
+		Expect(finding.Getter).To(Equal("GetChild()"))
+		Expect(finding.GetterFile).To(HaveSuffix("generated/A.pb.go"))
+		Expect(finding.Source).To(Equal("_ = p.Child"))
+
+		buf.Reset()
+		Expect(gettercheck.WriteJSONLines(&buf, r)).To(Succeed())
+		Expect(buf.String()).To(HavePrefix(`{"version":1,"file":`))
+		Expect(strings.Count(buf.String(), "\n")).To(Equal(1))
+	})
+
+	It("writes findings as SARIF", func(){
+		checker.UnusedDirectives = true
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child
+_ = "héllo"; _ = p.Child.Name //gettercheck:ignore expires=2000-01-01 legacy`)
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		var buf bytes.Buffer
+		Expect(gettercheck.WriteSARIF(&buf, r)).To(Succeed())
+		var log map[string]interface{}
+		Expect(json.Unmarshal(buf.Bytes(), &log)).To(Succeed())
+		ExpectValidSARIF(log)
+		Expect(log["$schema"]).To(Equal("https://json.schemastore.org/sarif-2.1.0.json"))
+
+		run := log["runs"].([]interface{})[0].(map[string]interface{})
+		rules := run["tool"].(map[string]interface{})["driver"].(map[string]interface{})["rules"].([]interface{})
+		Expect(rules).To(HaveLen(len(gettercheck.RuleNames())))
+		results := run["results"].([]interface{})
+		Expect(results).To(HaveLen(4))
+		for _, result := range results {
+			result := result.(map[string]interface{})
+			rule := rules[int(result["ruleIndex"].(float64))].(map[string]interface{})
+			Expect(result["ruleId"]).To(Equal(rule["id"]))
+		}
+
+		// The fixes replace the same text as -write, with columns counted
+		// in code points.
+		lines := strings.Split(ReadMain(), "\n")
+		var fixed []string
+		for _, result := range results[:3] {
+			result := result.(map[string]interface{})
+			Expect(result["ruleId"]).To(Equal("unused-getter"))
+			fix := result["fixes"].([]interface{})[0].(map[string]interface{})
+			change := fix["artifactChanges"].([]interface{})[0].(map[string]interface{})
+			replacement := change["replacements"].([]interface{})[0].(map[string]interface{})
+			region := replacement["deletedRegion"].(map[string]interface{})
+			line := []rune(lines[int(region["startLine"].(float64))-1])
+			start, end := int(region["startColumn"].(float64))-1, int(region["endColumn"].(float64))-1
+			text := replacement["insertedContent"].(map[string]interface{})["text"].(string)
+			fixed = append(fixed, string(line[:start])+text+string(line[end:]))
+		}
+		Expect(fixed).To(Equal([]string{
+			"_ = p.GetChild()",
+			`_ = "héllo"; _ = p.Child.GetName() //gettercheck:ignore expires=2000-01-01 legacy`,
+			`_ = "héllo"; _ = p.GetChild().Name //gettercheck:ignore expires=2000-01-01 legacy`,
+		}))
+		Expect(results[3].(map[string]interface{})["ruleId"]).To(Equal("unused-directive"))
+	})
+
+	It("validates SARIF logs against the schema", func(){
+		var buf bytes.Buffer
+		Expect(gettercheck.WriteSARIF(&buf, gettercheck.Result{})).To(Succeed())
+		var log map[string]interface{}
+		Expect(json.Unmarshal(buf.Bytes(), &log)).To(Succeed())
+		ExpectValidSARIF(log)
+
+		contents, err := ioutil.ReadFile("testdata/sarif-schema-2.1.0.json")
+		Expect(err).NotTo(HaveOccurred())
+		var schema map[string]interface{}
+		Expect(json.Unmarshal(contents, &schema)).To(Succeed())
+		run := log["runs"].([]interface{})[0].(map[string]interface{})
+		run["columnKind"] = "bytes"
+		run["results"] = []interface{}{map[string]interface{}{
+			"message":   map[string]interface{}{},
+			"locations": []interface{}{map[string]interface{}{
+				"physicalLocation": map[string]interface{}{
+					"artifactLocation": map[string]interface{}{"uri": "main.go"},
+					"region":           map[string]interface{}{"startLine": 0.0, "startColumn": 1.5},
+				},
+			}},
+			"fix": []interface{}{},
+		}}
+		delete(log, "version")
+		Expect(ValidateJSONSchema(schema, log)).To(ConsistOf(
+			"#: missing required property version",
+			"#/runs/0/columnKind: bytes is not one of [utf16CodeUnits unicodeCodePoints]",
+			HavePrefix("#/runs/0/results/0/message: matches none of"),
+			"#/runs/0/results/0/locations/0/physicalLocation/region/startLine: 0 is less than 1",
+			"#/runs/0/results/0/locations/0/physicalLocation/region/startColumn: 1.5 is not of type integer",
+			"#/runs/0/results/0: unknown property fix",
+		))
+	})
+
+	It("writes findings as checkstyle and JUnit XML", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child`)
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		r.Packages = append(r.Packages, "example.com/clean")
+
+		var buf bytes.Buffer
+		Expect(gettercheck.WriteCheckstyle(&buf, r)).To(Succeed())
+		var checkstyle struct {
+			Files []struct {
+				Name   string `xml:"name,attr"`
+				Errors []struct {
+					Line   int    `xml:"line,attr"`
+					Column int    `xml:"column,attr"`
+					Source string `xml:"source,attr"`
+				} `xml:"error"`
+			} `xml:"file"`
+		}
+		Expect(xml.Unmarshal(buf.Bytes(), &checkstyle)).To(Succeed())
+		Expect(checkstyle.Files).To(HaveLen(1))
+		Expect(checkstyle.Files[0].Name).To(HaveSuffix("testdata/src/main.go"))
+		Expect(checkstyle.Files[0].Errors).To(HaveLen(1))
+		Expect(checkstyle.Files[0].Errors[0].Line).To(Equal(10))
+		Expect(checkstyle.Files[0].Errors[0].Column).To(Equal(7))
+		Expect(checkstyle.Files[0].Errors[0].Source).To(Equal("gettercheck.unused-getter"))
+
+		buf.Reset()
+		Expect(gettercheck.WriteJUnit(&buf, r)).To(Succeed())
+		var junit struct {
+			Suites []struct {
+				Tests    int `xml:"tests,attr"`
+				Failures int `xml:"failures,attr"`
+				Cases    []struct {
+					Name    string `xml:"name,attr"`
+					Failure *struct {
+						Message string `xml:"message,attr"`
+						Text    string `xml:",chardata"`
+					} `xml:"failure"`
+				} `xml:"testcase"`
+			} `xml:"testsuite"`
+		}
+		Expect(xml.Unmarshal(buf.Bytes(), &junit)).To(Succeed())
+		Expect(junit.Suites).To(HaveLen(1))
+		suite := junit.Suites[0]
+		Expect([]int{suite.Tests, suite.Failures}).To(Equal([]int{2, 1}))
+		Expect(suite.Cases[0].Name).To(Equal("example.com/clean"))
+		Expect(suite.Cases[0].Failure).To(BeNil())
+		Expect(suite.Cases[1].Name).To(Equal(testPackage))
+		Expect(suite.Cases[1].Failure.Message).To(Equal("1 problem found"))
+		Expect(suite.Cases[1].Failure.Text).To(ContainSubstring("use GetChild() instead of the Child field"))
+	})
+
+	It("writes findings with format templates", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child`)
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		r.UnusedGetterError[0].Builds = []string{"default", "linux/arm64"}
+
+		Write := func(text string) string {
+			tmpl, err := gettercheck.ParseTemplate(text)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+			var buf bytes.Buffer
+			ExpectWithOffset(1, gettercheck.WriteTemplate(&buf, r, tmpl)).To(Succeed())
+			return buf.String()
+		}
+		pos := r.UnusedGetterError[0].Pos.String()
+		Expect(Write("default")).To(Equal(pos + ":\tGetChild()\t_ = p.Child\t[default linux/arm64]\n"))
+		Expect(Write("emacs")).To(Equal(pos + ": warning: unused getter: use GetChild() instead of the Child field\n"))
+		Expect(Write("{{base .Pos.Filename}}:{{.Pos.Line}}: use {{.Getter}} in {{.Function}}")).To(Equal("main.go:10: use GetChild() in main\n"))
+
+		_, err = gettercheck.ParseTemplate("{{.Pos")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("writes findings as GitHub workflow commands and rdjson", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child
+_ = []*Basic{}[0].Name`)
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		Expect(r.UnusedGetterError).To(HaveLen(2))
+		r.UnusedGetterError[1].Reason = "a reason, with: punctuation\nand 100%"
+
+		var buf bytes.Buffer
+		Expect(gettercheck.WriteGitHub(&buf, r)).To(Succeed())
+		file := r.UnusedGetterError[0].Pos.Filename
+		Expect(strings.Split(buf.String(), "\n")).To(Equal([]string{
+			"::warning file=" + file + ",line=10,col=7,endLine=10,endColumn=12,title=gettercheck%3A unused-getter::unused getter: use GetChild() instead of the Child field",
+			"::warning file=" + file + ",line=11,col=19,endLine=11,endColumn=23,title=gettercheck%3A unused-getter::unused getter: use GetName() instead of the Name field: a reason, with: punctuation%0Aand 100%25",
+			"",
+		}))
+
+		buf.Reset()
+		Expect(gettercheck.WriteRDJSON(&buf, r)).To(Succeed())
+		var result struct {
+			Diagnostics []struct {
+				Location struct {
+					Path  string
+					Range struct {
+						Start, End struct{ Line, Column int }
+					}
+				}
+				Suggestions []struct {
+					Range struct {
+						Start, End struct{ Line, Column int }
+					}
+					Text string
+				}
+			}
+		}
+		Expect(json.Unmarshal(buf.Bytes(), &result)).To(Succeed())
+		Expect(result.Diagnostics).To(HaveLen(2))
+		d := result.Diagnostics[0]
+		Expect(d.Location.Path).To(Equal(file))
+		Expect(d.Suggestions).To(HaveLen(1))
+		Expect(d.Suggestions[0].Text).To(Equal("GetChild()"))
+		Expect(d.Suggestions[0].Range).To(Equal(d.Location.Range))
+		Expect(d.Suggestions[0].Range.Start.Column).To(Equal(7))
+		Expect(d.Suggestions[0].Range.End.Column).To(Equal(12))
+		Expect(result.Diagnostics[1].Suggestions).To(BeEmpty())
+	})
+
+	It("only reports findings on the lines a diff adds or modifies", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child
+_ = p.Child
+_ = p.Child`)
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		Expect(r.UnusedGetterError).To(HaveLen(3))
+
+		changed, err := gettercheck.ParseUnifiedDiff(strings.NewReader(`diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -9,4 +9,5 @@ func main() {
+ p := &Parent{Child: &Basic{}}
+--- removed
++_ = p.Child
+ _ = p.Child
++_ = p.Child
+ }
+\ No newline at end of file
+diff --git a/removed.go b/removed.go
+--- a/removed.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package src
+`), "testdata/src")
+		Expect(err).NotTo(HaveOccurred())
+		errs := changed.Filter(r).UnusedGetterError
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Pos.Line).To(Equal(10))
+		Expect(errs[1].Pos.Line).To(Equal(12))
+		Expect(r.UnusedGetterError).To(HaveLen(3))
+
+		_, err = gettercheck.ParseUnifiedDiff(strings.NewReader("+++ b/main.go\n@@ -1 +1 @@\nfoo\n"), "testdata/src")
+		Expect(err).To(MatchError(ContainSubstring("unexpected line in hunk")))
+	})
+
+	It("only fixes findings on the lines a diff adds or modifies", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child
+_ = p.Child`)
+		changed, err := gettercheck.ParseUnifiedDiff(strings.NewReader(`--- a/main.go
++++ b/main.go
+@@ -10,0 +11 @@ func main() {
++_ = p.Child
+`), "testdata/src")
+		Expect(err).NotTo(HaveOccurred())
+		checker.ChangedLines = changed
+		checker.WriteGetters, checker.DiffGetters = true, true
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		r := checker.CheckPackage(pkgs[0])
+		Expect(r.UnusedGetterError).To(HaveLen(1))
+		Expect(r.UnusedGetterError[0].Pos.Line).To(Equal(11))
+		Expect(r.Diffs).To(HaveLen(1))
+		Expect(strings.Count(r.Diffs[0].Hunks, "\n+")).To(Equal(1))
+		Expect(ReadMain()).To(ContainSubstring("_ = p.Child\n_ = p.GetChild()"))
+	})
+
+	It("checks overlaid files as loaded instead of as on disk", func(){
+		WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.GetChild()`)
+		filename, err := filepath.Abs("testdata/src/main.go")
+		Expect(err).NotTo(HaveOccurred())
+		checker.Overlay = map[string][]byte{
+			filename: []byte(strings.Replace(ReadMain(), "_ = p.GetChild()", "_ = p.Child", 1)),
+		}
+		pkgs, err := checker.LoadPackages(testPackage)
+		Expect(err).NotTo(HaveOccurred())
+		errs := checker.CheckPackage(pkgs[0]).UnusedGetterError
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Pos.Filename).To(Equal(filename))
+		Expect(errs[0].Pos.Line).To(Equal(10))
+		Expect(errs[0].Line).To(Equal("_ = p.Child"))
+	})
+
+	Context("with a configuration file", func(){
+		WriteConfig := func(contents string) {
+			err := ioutil.WriteFile("testdata/src/"+gettercheck.ConfigFile, []byte(contents), 0644)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func(){
+			WriteTestFileBoostrap(`
+p := &Parent{Child: &Basic{}}
+_ = p.Child.Name`)
+		})
+
+		AfterEach(func(){
+			Expect(os.Remove("testdata/src/"+gettercheck.ConfigFile)).To(Succeed())
+		})
+
+		It("applies its settings and the overrides for each directory", func(){
+			WriteConfig(`
+profiles: [protobuf]
+rules:
+  unused-directive: true
+overrides:
+  - path: .
+    ignore: ['Parent\.Child']
+  - path: generated
+    rules:
+      unused-getter: false
+`)
+			config, err := gettercheck.LoadConfig("", "testdata/src")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).NotTo(BeNil())
+			Expect(config.Apply(checker)).To(Succeed())
+			Expect(checker.UnusedDirectives).To(BeTrue())
+			ExpectUnusedGetterResult(UnusedGetterExpectation{
+				ExpectedGetter:  "GetName()",
+				ExpectedLinePos: "10:13",
+			})
+		})
+
+		It("is used by the analyzer", func(){
+			WriteConfig(`
+overrides:
+  - path: .
+    rules:
+      unused-getter: false
+`)
+			diagnostics, _ := RunAnalyzer()
+			Expect(diagnostics).To(BeEmpty())
+		})
+
+		It("is loaded once per directory by the analyzer", func(){
+			WriteConfig(`
+overrides:
+  - path: .
+    rules:
+      unused-getter: false
+`)
+			diagnostics, _ := RunAnalyzer()
+			Expect(diagnostics).To(BeEmpty())
+			WriteConfig("rules:\n  unused-getter: true\n")
+			diagnostics, _ = RunAnalyzer()
+			Expect(diagnostics).To(BeEmpty())
+			gettercheck.ResetAnalyzerConfigs()
+			diagnostics, _ = RunAnalyzer()
+			Expect(diagnostics).To(HaveLen(2))
+		})
+
+		It("rejects unknown settings", func(){
+			WriteConfig("rules:\n  unused-everything: true\n")
+			_, err := gettercheck.LoadConfig("", "testdata/src")
+			Expect(err).To(MatchError(ContainSubstring(`unknown rule "unused-everything"`)))
+			WriteConfig("ignore-everything: true\n")
+			_, err = gettercheck.LoadConfig("", "testdata/src")
+			Expect(err).To(MatchError(ContainSubstring("field ignore-everything not found")))
+		})
+	})
+
+	Context("with files behind build constraints", func(){
+		BeforeEach(func(){
+			WriteTestFileBoostrap(``)
+			err := ioutil.WriteFile("testdata/src/integration.go", []byte(`//go:build integration
+// +build integration
+
+package src
+
+import (
+	. "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+)
+
+var _ = (&Basic{}).Name`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func(){
+			Expect(os.Remove("testdata/src/integration.go")).To(Succeed())
+		})
+
+		It("doesn't check them by default", func(){
+			ExpectUnusedGetterResult()
+		})
+
+		It("merges the results of checking under several build contexts", func(){
+			var matrix gettercheck.BuildContexts
+			Expect(matrix.Set("default,linux/arm64:integration")).To(Succeed())
+			WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+			result, err := checker.CheckMatrix(matrix, testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			errs := result.UnusedGetterError
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0].Pos.Filename).To(HaveSuffix("integration.go"))
+			Expect(errs[0].Builds).To(Equal([]string{"linux/arm64:integration"}))
+			Expect(errs[1].Pos.Filename).To(HaveSuffix("main.go"))
+			Expect(errs[1].Builds).To(Equal([]string{"default", "linux/arm64:integration"}))
+		})
+
+		It("fixes the files of each build context", func(){
+			var matrix gettercheck.BuildContexts
+			Expect(matrix.Set("default,linux/arm64:integration")).To(Succeed())
+			WriteTestFileBoostrap(`
+b := &Basic{}
+_ = b.Name`)
+			checker.WriteGetters = true
+			result, err := checker.CheckMatrix(matrix, testPackage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.ModifiedFiles).To(ConsistOf(HaveSuffix("integration.go"), HaveSuffix("main.go")))
+			Expect(ReadMain()).To(ContainSubstring("_ = b.GetName()"))
+			contents, err := ioutil.ReadFile("testdata/src/integration.go")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("(&Basic{}).GetName()"))
+		})
+
+		It("checks them when their tags are set", func(){
+			checker.Build.Tags = []string{"integration"}
+			ExpectUnusedGetterResult(UnusedGetterExpectation{
+				ExpectedGetter:  "GetName()",
+				ExpectedLinePos: "10:20",
+			})
+		})
+	})
+
 })
 
 type UnusedGetterExpectation struct {
@@ -163,6 +1521,171 @@ type UnusedGetterExpectation struct {
 	ExpectedLinePos string
 }
 
+// ApplyEdits applies edits to testdata/src/main.go and returns the result,
+// without writing it back.
+func ApplyEdits(fset *token.FileSet, edits []analysis.TextEdit) string {
+	contents, err := ioutil.ReadFile("testdata/src/main.go")
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Pos > edits[j].Pos })
+	for _, edit := range edits {
+		start, end := fset.Position(edit.Pos).Offset, fset.Position(edit.End).Offset
+		contents = append(contents[:start], append(edit.NewText, contents[end:]...)...)
+	}
+	return string(contents)
+}
+
+// ExpectValidSARIF validates log against the SARIF 2.1.0 JSON schema in
+// testdata.
+func ExpectValidSARIF(log interface{}) {
+	contents, err := ioutil.ReadFile("testdata/sarif-schema-2.1.0.json")
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	var schema map[string]interface{}
+	ExpectWithOffset(1, json.Unmarshal(contents, &schema)).To(Succeed())
+	ExpectWithOffset(1, ValidateJSONSchema(schema, log)).To(BeEmpty())
+}
+
+// ValidateJSONSchema returns where and how instance, decoded from JSON, fails
+// to validate against schema. Only the draft-07 keywords the SARIF schema
+// uses are supported, and only local references.
+func ValidateJSONSchema(schema map[string]interface{}, instance interface{}) []string {
+	return validateJSONSchema(schema, schema, instance, "#")
+}
+
+// validateJSONSchema validates v, at the JSON pointer at, against s, whose
+// references are resolved in root.
+func validateJSONSchema(root, s map[string]interface{}, v interface{}, at string) []string {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, at+": "+fmt.Sprintf(format, args...))
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		resolved := interface{}(root)
+		for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			resolved = resolved.(map[string]interface{})[name]
+		}
+		problems = append(problems, validateJSONSchema(root, resolved.(map[string]interface{}), v, at)...)
+	}
+	if t, ok := s["type"]; ok {
+		types, ok := t.([]interface{})
+		if !ok {
+			types = []interface{}{t}
+		}
+		matches := false
+		for _, t := range types {
+			switch t {
+			case "object":
+				_, ok = v.(map[string]interface{})
+			case "array":
+				_, ok = v.([]interface{})
+			case "string":
+				_, ok = v.(string)
+			case "boolean":
+				_, ok = v.(bool)
+			case "number":
+				_, ok = v.(float64)
+			case "integer":
+				n, isNumber := v.(float64)
+				ok = isNumber && n == float64(int64(n))
+			case "null":
+				ok = v == nil
+			}
+			matches = matches || ok
+		}
+		if !matches {
+			problem("%v is not of type %v", v, t)
+			return problems
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(e, v)
+		}
+		if !found {
+			problem("%v is not one of %v", v, enum)
+		}
+	}
+	switch v := v.(type) {
+	case float64:
+		if minimum, ok := s["minimum"].(float64); ok && v < minimum {
+			problem("%v is less than %v", v, minimum)
+		}
+		if maximum, ok := s["maximum"].(float64); ok && v > maximum {
+			problem("%v is greater than %v", v, maximum)
+		}
+	case string:
+		if pattern, ok := s["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(v) {
+			problem("%q doesn't match %s", v, pattern)
+		}
+		switch s["format"] {
+		case "uri":
+			if u, err := url.Parse(v); err != nil || !u.IsAbs() {
+				problem("%q is not an absolute URI", v)
+			}
+		case "uri-reference":
+			if _, err := url.Parse(v); err != nil {
+				problem("%q is not a URI reference", v)
+			}
+		}
+	case []interface{}:
+		if minItems, ok := s["minItems"].(float64); ok && len(v) < int(minItems) {
+			problem("has fewer than %v items", minItems)
+		}
+		if unique, _ := s["uniqueItems"].(bool); unique {
+			for i := range v {
+				for j := i + 1; j < len(v); j++ {
+					if reflect.DeepEqual(v[i], v[j]) {
+						problem("items %d and %d are equal", i, j)
+					}
+				}
+			}
+		}
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, validateJSONSchema(root, items, item, fmt.Sprintf("%s/%d", at, i))...)
+			}
+		}
+	case map[string]interface{}:
+		required, _ := s["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				problem("missing required property %s", name)
+			}
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		for name, value := range v {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				problems = append(problems, validateJSONSchema(root, property, value, at+"/"+name)...)
+				continue
+			}
+			switch additional := s["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					problem("unknown property %s", name)
+				}
+			case map[string]interface{}:
+				problems = append(problems, validateJSONSchema(root, additional, value, at+"/"+name)...)
+			}
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, alternative := range anyOf {
+			matched = matched || len(validateJSONSchema(root, alternative.(map[string]interface{}), v, at)) == 0
+		}
+		if !matched {
+			problem("matches none of %v", anyOf)
+		}
+	}
+	return problems
+}
+
+func ReadMain() string {
+	contents, err := ioutil.ReadFile("testdata/src/main.go")
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	return string(contents)
+}
+
 func WriteTestFileBoostrap(contents string){
 	toWrite := fmt.Sprintf(`package src
 
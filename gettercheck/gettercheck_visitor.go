@@ -6,7 +6,9 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
+	"io"
 	"os"
 	"strings"
 
@@ -20,8 +22,72 @@ type visitor struct {
 	fset      *token.FileSet
 	lines     map[string][]string
 
-	errors  []UnusedGetterError
-	imports map[string]*packages.Package
+	findings []finding
+	// operands are the expressions being assigned to, incremented or having
+	// their address taken.
+	operands map[ast.Expr]bool
+	imports  map[string]*packages.Package
+	profiles Profiles
+	// ignores suppress findings in the package with path pkgPath.
+	ignores Ignores
+	pkgPath string
+	// function is the name of the top-level declaration being visited.
+	function string
+	// method is the method declared by the top-level declaration being
+	// visited, if it declares one.
+	method *types.Func
+}
+
+// namedType returns typ, or what typ points to, if it is a named type or an
+// alias of one, or nil otherwise.
+func namedType(typ types.Type) *types.Named {
+	if typ == nil {
+		return nil
+	}
+	typ = types.Unalias(typ)
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = types.Unalias(ptr.Elem())
+	}
+	named, _ := typ.(*types.Named)
+	return named
+}
+
+// embedded returns the embedded fields selection goes through to reach the
+// selected field, outermost first, and the named type declaring the field,
+// or nil if it isn't declared by a named type.
+func embedded(selection *types.Selection) ([]*types.Var, *types.Named) {
+	var path []*types.Var
+	typ := selection.Recv()
+	index := selection.Index()
+	for _, i := range index[:len(index)-1] {
+		if ptr, ok := coreType(typ).(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		st, ok := coreType(typ).(*types.Struct)
+		if !ok {
+			return nil, nil
+		}
+		field := st.Field(i)
+		path = append(path, field)
+		typ = field.Type()
+	}
+	return path, namedType(typ)
+}
+
+// profileOf returns the first of the visitor's profiles that recognises named
+// as generated, or nil if none does. field is the field of named being
+// selected.
+func (v *visitor) profileOf(named *types.Named, field types.Object) *Profile {
+	if named == nil {
+		return nil
+	}
+	filename := v.fset.Position(field.Pos()).Filename
+	for _, p := range v.profiles {
+		if p.Recognize(named, filename) {
+			return p
+		}
+	}
+	return nil
 }
 
 // selectorAndFunc tries to get the selector and function from call expression.
@@ -122,30 +188,241 @@ func (v *visitor) argName(expr ast.Expr) string {
 	return t.String()
 }
 
-// TODO (dtcaciuc) collect token.Pos and then convert them to UnusedGetterError
-// after visitor is done running. This will allow to integrate more cleanly
-// with analyzer so that we don't have to convert Position back to Pos.
-func (v *visitor) addErrorAtPosition(position token.Pos, ident *ast.Ident, getterPos token.Position) {
-	pos := v.fset.Position(position)
-	lines, ok := v.lines[pos.Filename]
+// finding is an unused getter found by the visitor. Positions are collected as
+// token.Pos so that the analyzer can report exact ranges, and are converted to
+// an UnusedGetterError once the visitor is done running.
+type finding struct {
+	// pos and end delimit the selector expression accessing the field.
+	pos, end token.Pos
+	// fieldPos is the position of the selected field, which the getter call
+	// replaces up to end.
+	fieldPos token.Pos
+	// getter is the method that should be called instead.
+	getter *types.Func
+	// reason explains why the getter can't be called instead of the field,
+	// if it can't. Such findings have no fix.
+	reason string
+	// function and selector identify the finding regardless of its
+	// position, see Fingerprint.
+	function, selector string
+	// receiver is the type of the expression the field is selected from,
+	// and field the name of the field.
+	receiver types.Type
+	field    string
+	// path selects the embedded field declaring the getter, such as
+	// "Basic.", when the getter isn't promoted like the field is.
+	path string
+}
+
+// call returns the getter call replacing the field.
+func (f finding) call() string {
+	return f.path + f.getter.Name() + "()"
+}
+
+// fixable reports whether the finding has a fix.
+func (f finding) fixable() bool {
+	return f.reason == ""
+}
+
+// edit returns the fix for the finding.
+func (f finding) edit() analysis.TextEdit {
+	return analysis.TextEdit{
+		Pos:     f.fieldPos,
+		End:     f.end,
+		NewText: []byte(f.call()),
+	}
+}
+
+// addFinding records that sel should call getter, through the embedded
+// fields in path, instead of selecting the field directly. reason is non-empty
+// if the getter can't be called.
+func (v *visitor) addFinding(sel *ast.SelectorExpr, getter *types.Func, path string, reason string) {
+	v.findings = append(v.findings, finding{
+		pos:      sel.Pos(),
+		end:      sel.End(),
+		fieldPos: sel.Sel.Pos(),
+		getter:   getter,
+		reason:   reason,
+		function: v.function,
+		selector: types.ExprString(sel),
+		receiver: v.typesInfo.TypeOf(sel.X),
+		field:    sel.Sel.Name,
+		path:     path,
+	})
+}
+
+// addOperand records that the value of x mustn't be replaced by a getter call.
+func (v *visitor) addOperand(x ast.Expr) {
+	if v.operands == nil {
+		v.operands = make(map[ast.Expr]bool)
+	}
+	v.operands[astutil.Unparen(x)] = true
+}
+
+// cannotCall returns why the getter can't be called on x through the
+// embedded fields in path, or the empty string if it can. Getters with pointer
+// receivers can only be called on pointers and addressable values.
+func (v *visitor) cannotCall(x ast.Expr, path []*types.Var, getter *types.Func) string {
+	typ := v.typesInfo.TypeOf(x)
+	addressable := v.addressable(x)
+	recv := types.ExprString(x)
+	for _, field := range path {
+		if ptr, ok := coreType(typ).(*types.Pointer); ok {
+			// Fields of pointed to structs are addressable
+			typ, addressable = ptr.Elem(), true
+		}
+		typ = field.Type()
+		recv += "." + field.Name()
+	}
+	if _, ok := coreType(typ).(*types.Pointer); ok {
+		return ""
+	}
+	if types.NewMethodSet(typ).Lookup(getter.Pkg(), getter.Name()) != nil {
+		return ""
+	}
+	if addressable {
+		return ""
+	}
+	if tparam := v.undeterminedCore(x); tparam != nil {
+		return fmt.Sprintf("%s has a pointer receiver and the core type of %s is not determined", getter.Name(), tparam.Obj().Name())
+	}
+	return fmt.Sprintf("%s has a pointer receiver and %s is not addressable", getter.Name(), recv)
+}
+
+// undeterminedCore returns the type parameter without a core type whose
+// value x indexes, which prevents telling whether x is addressable, or nil if
+// there is none.
+func (v *visitor) undeterminedCore(x ast.Expr) *types.TypeParam {
+	switch x := astutil.Unparen(x).(type) {
+	case *ast.SelectorExpr:
+		if sel, ok := v.typesInfo.Selections[x]; ok && sel.Kind() == types.FieldVal && !sel.Indirect() {
+			return v.undeterminedCore(x.X)
+		}
+	case *ast.IndexExpr:
+		if tparam, ok := v.typesInfo.TypeOf(x.X).(*types.TypeParam); ok && coreType(tparam) == nil {
+			return tparam
+		}
+		if _, ok := coreType(v.typesInfo.TypeOf(x.X)).(*types.Array); ok {
+			return v.undeterminedCore(x.X)
+		}
+	}
+	return nil
+}
+
+// promotedPath returns the embedded fields to select on x before calling
+// getter, which is declared by the type embedded through path: the shortest
+// prefix of path the getter is promoted through. Another field or method of
+// the same name may shadow the getter, or several make it ambiguous, at the
+// shallower depths.
+func (v *visitor) promotedPath(x ast.Expr, path []*types.Var, getter *types.Func) []*types.Var {
+	typ := v.typesInfo.TypeOf(x)
+	for i, field := range path {
+		if obj, _, _ := types.LookupFieldOrMethod(typ, true, getter.Pkg(), getter.Name()); obj == getter {
+			return path[:i]
+		}
+		typ = field.Type()
+	}
+	return path
+}
+
+// addressable reports whether x is addressable, as defined by the Go spec.
+func (v *visitor) addressable(x ast.Expr) bool {
+	switch x := astutil.Unparen(x).(type) {
+	case *ast.Ident:
+		_, ok := v.typesInfo.ObjectOf(x).(*types.Var)
+		return ok
+	case *ast.StarExpr:
+		return true
+	case *ast.SelectorExpr:
+		sel, ok := v.typesInfo.Selections[x]
+		if !ok {
+			// A qualified identifier
+			_, ok := v.typesInfo.ObjectOf(x.Sel).(*types.Var)
+			return ok
+		}
+		if sel.Kind() != types.FieldVal {
+			return false
+		}
+		return sel.Indirect() || v.addressable(x.X)
+	case *ast.IndexExpr:
+		switch t := coreType(v.typesInfo.TypeOf(x.X)).(type) {
+		case *types.Slice:
+			return true
+		case *types.Array:
+			return v.addressable(x.X)
+		case *types.Pointer:
+			_, ok := coreType(t.Elem()).(*types.Array)
+			return ok
+		}
+	}
+	return false
+}
+
+// unusedGetterErrors converts the visitor's findings to UnusedGetterErrors.
+func (v *visitor) unusedGetterErrors() []UnusedGetterError {
+	errors := make([]UnusedGetterError, 0, len(v.findings))
+	for _, f := range v.findings {
+		errors = append(errors, v.unusedGetterError(f))
+	}
+	return errors
+}
+
+// fileLines returns the lines of filename, reading them only the first time.
+func (v *visitor) fileLines(filename string) []string {
+	lines, ok := v.lines[filename]
 	if !ok {
-		lines = readfile(pos.Filename)
-		v.lines[pos.Filename] = lines
+		lines = readfile(filename)
+		v.lines[filename] = lines
 	}
+	return lines
+}
+
+// readLines reads the lines of the files with findings, so that they are
+// reported as they were before being fixed.
+func (v *visitor) readLines() {
+	for _, f := range v.findings {
+		v.fileLines(v.fset.Position(f.fieldPos).Filename)
+	}
+}
+
+func (v *visitor) unusedGetterError(f finding) UnusedGetterError {
+	pos := v.fset.Position(f.fieldPos)
+	lines := v.fileLines(pos.Filename)
 
 	line := "??"
 	if pos.Line-1 < len(lines) {
 		line = strings.TrimSpace(lines[pos.Line-1])
 	}
 
-	var name = ident.Name
-
-	v.errors = append(v.errors, UnusedGetterError{
+	return UnusedGetterError{
 		pos,
-		getterPos,
+		v.fset.Position(f.getter.Pos()),
 		line,
-		name,
-	})
+		f.call(),
+		f.reason,
+		nil,
+		Fingerprint{v.pkgPath, f.function, f.selector},
+		v.fset.Position(f.end),
+		types.TypeString(f.receiver, nil),
+		f.field,
+	}
+}
+
+// declName returns the name of the function or method declared by decl, such
+// as F, T.M or (*T).M, or the empty string if decl doesn't declare one.
+func declName(decl ast.Node) string {
+	fn, ok := decl.(*ast.FuncDecl)
+	if !ok {
+		return ""
+	}
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := types.ExprString(fn.Recv.List[0].Type)
+	if strings.HasPrefix(recv, "*") {
+		recv = "(" + recv + ")"
+	}
+	return recv + "." + fn.Name.Name
 }
 
 func readfile(filename string) []string {
@@ -154,9 +431,12 @@ func readfile(filename string) []string {
 		return nil
 	}
 	defer f.Close()
+	return scanLines(f)
+}
 
+func scanLines(r io.Reader) []string {
 	var lines []string
-	var scanner = bufio.NewScanner(f)
+	var scanner = bufio.NewScanner(r)
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
@@ -168,19 +448,23 @@ func (v *visitor) Visit(c *astutil.Cursor) bool {
 	if node == nil {
 		return false
 	}
+	if _, ok := c.Parent().(*ast.File); ok {
+		v.function = declName(node)
+		v.method = nil
+		if fn, ok := node.(*ast.FuncDecl); ok && fn.Recv != nil {
+			v.method, _ = v.typesInfo.Defs[fn.Name].(*types.Func)
+		}
+	}
 	switch n := node.(type) {
 	case *ast.SelectorExpr:
+		// Fields being set or having their address taken can't be replaced
+		// by a getter
+		if v.operands[n] {
+			return true
+		}
 		// this switch controls for special cases where we may
 		// not want to use the getter
 		switch p := c.Parent().(type) {
-			case *ast.AssignStmt:
-				// If the parent is an assignment statement, we only want to
-				// report if it's not on the left hand side (e.g. we are not setting this value)
-				for _, i := range p.Lhs{
-					if n == i {
-						return true
-					}
-				}
 		case *ast.BinaryExpr:
 			if p.Op == token.EQL {
 				if i, ok := p.Y.(*ast.Ident); ok {
@@ -195,61 +479,73 @@ func (v *visitor) Visit(c *astutil.Cursor) bool {
 					}
 				}
 			}
-		case *ast.UnaryExpr:
-			if p.Op == token.AND {
-				return true
-			}
 		}
 
-		obj := v.typesInfo.ObjectOf(n.Sel)
-		switch obj.(type) {
-		case *types.Var:
-		default:
+		selection, ok := v.typesInfo.Selections[n]
+		if !ok || selection.Kind() != types.FieldVal {
 			return true
 		}
-		p := obj.Pos()
-		f := v.fset.File(p)
-		goPos := f.Position(p)
-		// If the variable is from a `.pb.go` file, it has a getter
+		// The field may be promoted from an embedded struct, which is the
+		// one that has to be generated
+		embeddedPath, named := embedded(selection)
+		// If the variable is a field of a generated type, it has a getter
 		// and the getter should be being used instead
-		if strings.Contains(goPos.String(), ".pb.go:") {
-			getter := fmt.Sprintf("Get%s", n.Sel.Name)
-			typ := v.typesInfo.TypeOf(n.X)
-			if method := FindMethod(typ, getter); method != nil {
-				mPos := method.Pos()
-				goMethodPos := v.fset.File(mPos).Position(mPos)
-				n.Sel.Name = getter + "()"
-				v.addErrorAtPosition(n.Sel.Pos(), n.Sel, goMethodPos)
-				c.Replace(n)
+		if profile := v.profileOf(named, selection.Obj()); profile != nil && !v.ignores.ignored(v.pkgPath, named, n.Sel.Name) {
+			getter := profile.Getter(n.Sel.Name)
+			// The getter itself reads the field, calling it there instead
+			// would recurse
+			if method := FindMethod(named, getter); method != nil && method.Origin() != v.method {
+				path := v.promotedPath(n.X, embeddedPath, method)
+				reason := v.cannotSelect(path)
+				if reason == "" {
+					reason = v.cannotCall(n.X, path, method)
+				}
+				v.addFinding(n, method, selectorPath(path), reason)
 			}
 		}
 		return true
-	case *ast.KeyValueExpr:
-		res := astutil.Apply(n.Value, v.Visit, nil)
-		n.Value = res.(ast.Expr)
-		c.Replace(n)
+	case *ast.AssignStmt:
+		// Fields on the left hand side of an assignment are being set, not read
+		for _, lhs := range n.Lhs {
+			v.addOperand(lhs)
+		}
+		return true
+	case *ast.IncDecStmt:
+		v.addOperand(n.X)
 		return true
 	case *ast.UnaryExpr:
-		switch x := n.X.(type) {
-		case *ast.SelectorExpr:
-			res := astutil.Apply(x.X, v.Visit, nil)
-			x.X = res.(ast.Expr)
-			// todo: potential bug?
-			c.Replace(n)
-			return true
-		}
-		uV := &UnaryVisitor{
-			typesInfo: v.typesInfo,
-			fset:      v.fset,
+		if n.Op == token.AND {
+			v.addOperand(n.X)
 		}
-		res := astutil.Apply(n.X, uV.Visit, nil)
-		n.X = res.(ast.Expr)
-		c.Replace(n)
 		return true
 	}
 	return true
 }
 
+// selectorPath returns the selector expression of the embedded fields in
+// path, followed by a dot, such as "Parent.Basic.".
+func selectorPath(path []*types.Var) string {
+	var b strings.Builder
+	for _, field := range path {
+		b.WriteString(field.Name())
+		b.WriteByte('.')
+	}
+	return b.String()
+}
+
+// cannotSelect returns why the embedded fields in path can't be selected,
+// or the empty string if they can.
+func (v *visitor) cannotSelect(path []*types.Var) string {
+	for _, field := range path {
+		if !field.Exported() && field.Pkg() != v.types {
+			return fmt.Sprintf("the embedded field %s is not exported", field.Name())
+		}
+	}
+	return ""
+}
+
+// FindMethod returns the method named methodName declared by p, or by what p
+// points to, or nil if there is none. Promoted methods aren't returned.
 func FindMethod(p types.Type, methodName string) *types.Func {
 	switch typ := p.(type) {
 	case *types.Pointer:
@@ -266,38 +562,6 @@ func FindMethod(p types.Type, methodName string) *types.Func {
 	return nil
 }
 
-type UnaryVisitor struct {
-	typesInfo *types.Info
-	fset      *token.FileSet
-}
-
-func (v *UnaryVisitor) Visit(c *astutil.Cursor) bool {
-	node := c.Node()
-	if node == nil {
-		return false
-	}
-	switch x := node.(type) {
-	case *ast.ParenExpr:
-		if sel, ok := x.X.(*ast.SelectorExpr); ok {
-			rv := &visitor{
-				typesInfo: v.typesInfo,
-				fset:      v.fset,
-			}
-			res := astutil.Apply(sel.X, rv.Visit, nil)
-			sel.X = res.(ast.Expr)
-		}
-		if sel, ok := x.X.(*ast.ParenExpr); ok {
-			res := astutil.Apply(sel.X, v.Visit, nil)
-			sel.X = res.(ast.Expr)
-		}
-	case *ast.SelectorExpr:
-		res := astutil.Apply(x.X, v.Visit, nil)
-		x.X = res.(ast.Expr)
-	}
-	c.Replace(node)
-	return true
-}
-
 func contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
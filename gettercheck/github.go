@@ -0,0 +1,58 @@
+package gettercheck
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// WriteGitHub writes r to w as GitHub Actions workflow commands, which show
+// the unused getter and directive errors as warning annotations.
+func WriteGitHub(w io.Writer, r Result) error {
+	for _, e := range r.UnusedGetterError {
+		err := writeGitHubCommand(w, "warning", []githubProperty{
+			{"file", filepath.ToSlash(e.Pos.Filename)},
+			{"line", fmt.Sprint(e.Pos.Line)},
+			{"col", fmt.Sprint(e.Pos.Column)},
+			{"endLine", fmt.Sprint(e.End.Line)},
+			{"endColumn", fmt.Sprint(e.End.Column)},
+			{"title", "gettercheck: " + RuleUnusedGetter},
+		}, e.message())
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range r.DirectiveErrors {
+		err := writeGitHubCommand(w, "warning", []githubProperty{
+			{"file", filepath.ToSlash(e.Pos.Filename)},
+			{"line", fmt.Sprint(e.Pos.Line)},
+			{"col", fmt.Sprint(e.Pos.Column)},
+			{"title", "gettercheck: " + RuleUnusedDirective},
+		}, fmt.Sprintf("%s: %s", e.Text, e.Problem))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type githubProperty struct {
+	name, value string
+}
+
+// writeGitHubCommand writes a workflow command, escaping its properties and
+// message as the runner expects.
+func writeGitHubCommand(w io.Writer, command string, properties []githubProperty, message string) error {
+	escaped := make([]string, 0, len(properties))
+	for _, p := range properties {
+		escaped = append(escaped, p.name+"="+githubPropertyEscaper.Replace(p.value))
+	}
+	_, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(escaped, ","), githubDataEscaper.Replace(message))
+	return err
+}
+
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
@@ -0,0 +1,90 @@
+package gettercheck
+
+import (
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+)
+
+// Ignore suppresses the findings for the fields it matches.
+type Ignore struct {
+	// Package, if set, must match the path of the package the field is
+	// accessed in.
+	Package *regexp.Regexp
+
+	// Field must match the name of the field qualified by the name of its
+	// message type, as in "Basic.Name".
+	Field *regexp.Regexp
+}
+
+// Matches reports whether the field of message, accessed in the package
+// with the given path, is ignored.
+func (i Ignore) Matches(pkgPath, message, field string) bool {
+	if i.Package != nil && !i.Package.MatchString(pkgPath) {
+		return false
+	}
+	return i.Field.MatchString(message + "." + field)
+}
+
+// Ignores is a list of ignore rules. It implements flag.Value, so it can be
+// set from a comma separated list of `[pkg:]field` pairs of regular
+// expressions, see Ignore. Each use of the flag adds to the list.
+type Ignores []Ignore
+
+func (i *Ignores) String() string {
+	pairs := make([]string, 0, len(*i))
+	for _, ignore := range *i {
+		prefix := ""
+		if ignore.Package != nil {
+			prefix = ignore.Package.String() + ":"
+		}
+		pairs = append(pairs, prefix+ignore.Field.String())
+	}
+	return fmt.Sprintf("%q", strings.Join(pairs, ","))
+}
+
+func (i *Ignores) Set(s string) error {
+	if s == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		ignore, err := ParseIgnore(pair)
+		if err != nil {
+			return err
+		}
+		*i = append(*i, ignore)
+	}
+	return nil
+}
+
+// ParseIgnore parses a single `[pkg:]field` pair of regular expressions.
+func ParseIgnore(s string) (Ignore, error) {
+	var ignore Ignore
+	re := s
+	if colonIndex := strings.Index(s, ":"); colonIndex != -1 {
+		pkg, err := regexp.Compile(s[:colonIndex])
+		if err != nil {
+			return Ignore{}, err
+		}
+		ignore.Package = pkg
+		re = s[colonIndex+1:]
+	}
+	field, err := regexp.Compile(re)
+	if err != nil {
+		return Ignore{}, err
+	}
+	ignore.Field = field
+	return ignore, nil
+}
+
+// ignored reports whether any of the rules ignores the field of message,
+// accessed in the package with the given path.
+func (i Ignores) ignored(pkgPath string, message *types.Named, field string) bool {
+	for _, ignore := range i {
+		if ignore.Matches(pkgPath, message.Obj().Name(), field) {
+			return true
+		}
+	}
+	return false
+}
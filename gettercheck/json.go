@@ -0,0 +1,108 @@
+package gettercheck
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// JSONSchemaVersion is the version of the schema of the JSON output. It is
+// incremented whenever a field is removed or changes meaning. New fields may
+// be added without incrementing it.
+const JSONSchemaVersion = 1
+
+// JSONReport is the document written by WriteJSON.
+type JSONReport struct {
+	// Version is JSONSchemaVersion.
+	Version  int           `json:"version"`
+	Findings []JSONFinding `json:"findings"`
+}
+
+// JSONFinding is an UnusedGetterError as written by WriteJSON and
+// WriteJSONLines. Lines and columns start at 1, and columns count bytes.
+type JSONFinding struct {
+	// File, Line and Column are the position of the field.
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"col"`
+	// EndLine and EndColumn are the position right after the selector
+	// expression.
+	EndLine   int `json:"end_line"`
+	EndColumn int `json:"end_col"`
+	// Package is the import path of the package the field is accessed in.
+	Package string `json:"pkg"`
+	// Function is the function or method the field is accessed in, if any.
+	Function string `json:"function,omitempty"`
+	// Selector is the text of the selector expression.
+	Selector string `json:"selector"`
+	// Receiver is the type of the expression the field is selected from.
+	Receiver string `json:"receiver"`
+	Field    string `json:"field"`
+	// Getter is the call that should replace the field, such as GetName().
+	Getter       string `json:"getter"`
+	GetterFile   string `json:"getter_file"`
+	GetterLine   int    `json:"getter_line"`
+	GetterColumn int    `json:"getter_col"`
+	// Source is the line of source code the field is accessed on, trimmed.
+	Source string `json:"source"`
+	// Reason explains why the getter can't be used as is, if it can't.
+	Reason string `json:"reason,omitempty"`
+	// Builds lists the build contexts the field is accessed in, when
+	// checking under several.
+	Builds []string `json:"builds,omitempty"`
+}
+
+// NewJSONFinding converts e to its JSON representation.
+func NewJSONFinding(e UnusedGetterError) JSONFinding {
+	return JSONFinding{
+		File:         e.Pos.Filename,
+		Line:         e.Pos.Line,
+		Column:       e.Pos.Column,
+		EndLine:      e.End.Line,
+		EndColumn:    e.End.Column,
+		Package:      e.Fingerprint.Package,
+		Function:     e.Fingerprint.Function,
+		Selector:     e.Fingerprint.Selector,
+		Receiver:     e.Receiver,
+		Field:        e.Field,
+		Getter:       e.FuncName,
+		GetterFile:   e.GetterPos.Filename,
+		GetterLine:   e.GetterPos.Line,
+		GetterColumn: e.GetterPos.Column,
+		Source:       strings.TrimSpace(e.Line),
+		Reason:       e.Reason,
+		Builds:       e.Builds,
+	}
+}
+
+// WriteJSON writes the unused getter errors of r to w as a JSONReport.
+func WriteJSON(w io.Writer, r Result) error {
+	report := JSONReport{Version: JSONSchemaVersion, Findings: []JSONFinding{}}
+	for _, e := range r.UnusedGetterError {
+		report.Findings = append(report.Findings, NewJSONFinding(e))
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// jsonLine is a line written by WriteJSONLines.
+type jsonLine struct {
+	Version int `json:"version"`
+	JSONFinding
+}
+
+// WriteJSONLines writes the unused getter errors of r to w as JSON Lines: one
+// JSONFinding per line, along with a "version" field holding
+// JSONSchemaVersion.
+func WriteJSONLines(w io.Writer, r Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, e := range r.UnusedGetterError {
+		if err := encoder.Encode(jsonLine{JSONSchemaVersion, NewJSONFinding(e)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package gettercheck
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Profile describes the getter convention of a code generator: which types it
+// generates and what the getter for one of their fields is called.
+type Profile struct {
+	// Name identifies the profile on the command line and in the Analyzer's flags.
+	Name string
+
+	// Recognize reports whether typ was produced by the generator. filename is
+	// the file the selected field is declared in.
+	Recognize func(typ *types.Named, filename string) bool
+
+	// Getter returns the name of the getter method for the named field.
+	Getter func(field string) string
+}
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]*Profile{
+		"protobuf": {
+			Name: "protobuf",
+			Recognize: func(typ *types.Named, filename string) bool {
+				// The name of the file is only used as a fallback for messages
+				// that can't be recognised by their type.
+				return isProtoMessage(typ) || strings.HasSuffix(filename, ".pb.go")
+			},
+			Getter: prefixGet,
+		},
+		// gogo/protobuf generates messages implementing proto.Message like
+		// protoc-gen-go does, without the newer protoreflect methods.
+		"gogo": {
+			Name: "gogo",
+			Recognize: func(typ *types.Named, _ string) bool {
+				return FindMethod(typ, "ProtoMessage") != nil
+			},
+			Getter: prefixGet,
+		},
+		"thrift": {
+			Name: "thrift",
+			Recognize: func(typ *types.Named, _ string) bool {
+				return isThriftStruct(typ)
+			},
+			Getter: prefixGet,
+		},
+		// custom covers hand-written types with nil-safe getters: any type
+		// that declares a getter for a field is expected to have it used.
+		"custom": {
+			Name: "custom",
+			Recognize: func(*types.Named, string) bool {
+				return true
+			},
+			Getter: prefixGet,
+		},
+	}
+)
+
+// RegisterProfile makes a profile available by its name. Registering a profile
+// with the name of an existing one replaces it.
+func RegisterProfile(p *Profile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[p.Name] = p
+}
+
+// LookupProfile returns the registered profile with the given name.
+func LookupProfile(name string) (*Profile, bool) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	p, ok := profiles[name]
+	return p, ok
+}
+
+// ProfileNames returns the sorted names of all registered profiles.
+func ProfileNames() []string {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultProfiles returns the profiles used when none are selected.
+func DefaultProfiles() Profiles {
+	p, _ := LookupProfile("protobuf")
+	return Profiles{p}
+}
+
+// Profiles is a list of generator profiles. It implements flag.Value, so it
+// can be set from a comma separated list of registered profile names.
+type Profiles []*Profile
+
+func (p *Profiles) String() string {
+	names := make([]string, 0, len(*p))
+	for _, profile := range *p {
+		names = append(names, profile.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (p *Profiles) Set(s string) error {
+	var selected Profiles
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		profile, ok := LookupProfile(name)
+		if !ok {
+			return fmt.Errorf("unknown profile %q, must be one of %s", name, strings.Join(ProfileNames(), ", "))
+		}
+		selected = append(selected, profile)
+	}
+	*p = selected
+	return nil
+}
+
+func prefixGet(field string) string {
+	return "Get" + field
+}
+
+// isProtoMessage reports whether named is a generated protobuf message. A type
+// is considered a message if it declares one of the methods protoc-gen-go
+// generates for proto.Message and protoreflect.ProtoMessage, or if it carries
+// the generated `state protoimpl.MessageState` field.
+func isProtoMessage(named *types.Named) bool {
+	for _, name := range []string{"ProtoMessage", "ProtoReflect"} {
+		if FindMethod(named, name) != nil {
+			return true
+		}
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); f.Name() == "state" && isMessageState(f.Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMessageState reports whether t is protoimpl.MessageState. protoimpl only
+// aliases the type, so the package of the underlying declaration is checked too,
+// whether or not the alias is resolved already.
+func isMessageState(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Name() != "MessageState" || named.Obj().Pkg() == nil {
+		return false
+	}
+	switch named.Obj().Pkg().Path() {
+	case "google.golang.org/protobuf/runtime/protoimpl", "google.golang.org/protobuf/internal/impl":
+		return true
+	}
+	return false
+}
+
+// isThriftStruct reports whether named is a struct generated by the Apache
+// Thrift compiler, which declares Read and Write methods taking the protocol
+// to use, a thrift.TProtocol, as their last parameter.
+func isThriftStruct(named *types.Named) bool {
+	for _, name := range []string{"Read", "Write"} {
+		method := FindMethod(named, name)
+		if method == nil {
+			return false
+		}
+		params := method.Type().(*types.Signature).Params()
+		if params.Len() == 0 {
+			return false
+		}
+		protocol, ok := params.At(params.Len() - 1).Type().(*types.Named)
+		if !ok || protocol.Obj().Name() != "TProtocol" || protocol.Obj().Pkg() == nil || protocol.Obj().Pkg().Name() != "thrift" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package gettercheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// The types below follow reviewdog's Diagnostic Format, rdjson. Columns count
+// bytes, starting at 1.
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Severity    string             `json:"severity"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type rdjsonDiagnostic struct {
+	Message        string             `json:"message"`
+	Location       rdjsonLocation     `json:"location"`
+	Severity       string             `json:"severity"`
+	Code           rdjsonCode         `json:"code"`
+	Suggestions    []rdjsonSuggestion `json:"suggestions,omitempty"`
+	OriginalOutput string             `json:"original_output,omitempty"`
+}
+
+type rdjsonLocation struct {
+	Path  string      `json:"path"`
+	Range rdjsonRange `json:"range"`
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition  `json:"start"`
+	End   *rdjsonPosition `json:"end,omitempty"`
+}
+
+type rdjsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+}
+
+type rdjsonSuggestion struct {
+	Range rdjsonRange `json:"range"`
+	Text  string      `json:"text"`
+}
+
+// WriteRDJSON writes r to w in reviewdog's rdjson format. Fixable unused
+// getter errors carry the replacement -write would make as a suggestion.
+func WriteRDJSON(w io.Writer, r Result) error {
+	result := rdjsonResult{
+		Source:      rdjsonSource{Name: "gettercheck", URL: "https://github.com/saiskee/gettercheck"},
+		Severity:    "WARNING",
+		Diagnostics: []rdjsonDiagnostic{},
+	}
+	for _, e := range r.UnusedGetterError {
+		rng := rdjsonRange{
+			Start: rdjsonPosition{e.Pos.Line, e.Pos.Column},
+			End:   &rdjsonPosition{e.End.Line, e.End.Column},
+		}
+		d := rdjsonDiagnostic{
+			Message:        e.message(),
+			Location:       rdjsonLocation{filepath.ToSlash(e.Pos.Filename), rng},
+			Severity:       "WARNING",
+			Code:           rdjsonCode{RuleUnusedGetter},
+			OriginalOutput: fmt.Sprintf("%s:\t%s\t%s", e.Pos, e.FuncName, strings.TrimSpace(e.Line)),
+		}
+		if e.Reason == "" {
+			d.Suggestions = []rdjsonSuggestion{{Range: rng, Text: e.FuncName}}
+		}
+		result.Diagnostics = append(result.Diagnostics, d)
+	}
+	for _, e := range r.DirectiveErrors {
+		result.Diagnostics = append(result.Diagnostics, rdjsonDiagnostic{
+			Message: fmt.Sprintf("%s: %s", e.Text, e.Problem),
+			Location: rdjsonLocation{filepath.ToSlash(e.Pos.Filename), rdjsonRange{
+				Start: rdjsonPosition{e.Pos.Line, e.Pos.Column},
+			}},
+			Severity: "WARNING",
+			Code:     rdjsonCode{RuleUnusedDirective},
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(result)
+}
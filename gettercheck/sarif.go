@@ -0,0 +1,254 @@
+package gettercheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"net/url"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// ruleDescriptions describe each of the rules, see RuleNames.
+var ruleDescriptions = map[string]struct{ short, full string }{
+	RuleUnusedGetter: {
+		"Field accessed directly instead of through its getter",
+		"Generated getters handle nil messages and unset optional fields. Accessing the field directly panics on a nil message.",
+	},
+	RuleUnusedDirective: {
+		"Malformed, expired or unused suppression directive",
+		"A //gettercheck: directive that can't be parsed, has expired, or doesn't suppress any finding.",
+	},
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	FullDescription      sarifMessage       `json:"fullDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Fixes               []sarifFix        `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn"`
+	EndLine     int           `json:"endLine"`
+	EndColumn   int           `json:"endColumn"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// WriteSARIF writes r to w as a SARIF 2.1.0 log with a single run. Fixable
+// unused getter errors carry the fix -write would apply, and directive errors
+// are reported under the unused-directive rule.
+func WriteSARIF(w io.Writer, r Result) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "gettercheck",
+			InformationURI: "https://github.com/saiskee/gettercheck",
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	ruleIndex := make(map[string]int)
+	for i, name := range RuleNames() {
+		ruleIndex[name] = i
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   name,
+			ShortDescription:     sarifMessage{ruleDescriptions[name].short},
+			FullDescription:      sarifMessage{ruleDescriptions[name].full},
+			DefaultConfiguration: sarifConfiguration{"warning"},
+		})
+	}
+
+	columns := &sarifColumns{lines: make(map[string][]string)}
+	for _, e := range r.UnusedGetterError {
+		uri := sarifURI(e.Pos.Filename)
+		region := columns.region(e.Pos, e.End)
+		result := sarifResult{
+			RuleID:    RuleUnusedGetter,
+			RuleIndex: ruleIndex[RuleUnusedGetter],
+			Level:     "warning",
+			Message:   sarifMessage{e.message()},
+			Locations: []sarifLocation{{sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{uri},
+				Region:           region,
+			}}},
+			PartialFingerprints: map[string]string{
+				"gettercheckFingerprint/v1": e.Fingerprint.hash(),
+			},
+		}
+		if e.Reason == "" {
+			deleted := region
+			deleted.Snippet = nil
+			result.Fixes = []sarifFix{{
+				Description: sarifMessage{fmt.Sprintf("Use %s", e.FuncName)},
+				ArtifactChanges: []sarifArtifactChange{{
+					ArtifactLocation: sarifArtifactLocation{uri},
+					Replacements: []sarifReplacement{{
+						DeletedRegion:   deleted,
+						InsertedContent: sarifMessage{e.FuncName},
+					}},
+				}},
+			}}
+		}
+		run.Results = append(run.Results, result)
+	}
+	for _, e := range r.DirectiveErrors {
+		end := e.Pos
+		end.Column += len(e.Text)
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    RuleUnusedDirective,
+			RuleIndex: ruleIndex[RuleUnusedDirective],
+			Level:     "warning",
+			Message:   sarifMessage{fmt.Sprintf("%s: %s", e.Text, e.Problem)},
+			Locations: []sarifLocation{{sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{sarifURI(e.Pos.Filename)},
+				Region:           columns.region(e.Pos, end),
+			}}},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
+
+// hash returns a stable digest of the fingerprint.
+func (f Fingerprint) hash() string {
+	sum := sha256.Sum256([]byte(f.Package + "\x00" + f.Function + "\x00" + f.Selector))
+	return hex.EncodeToString(sum[:])
+}
+
+// sarifURI returns the URI of filename: a relative reference if it is
+// relative, and a file URI otherwise.
+func sarifURI(filename string) string {
+	u := url.URL{Path: filepath.ToSlash(filename)}
+	if filepath.IsAbs(filename) {
+		u.Scheme = "file"
+		if u.Path[0] != '/' {
+			u.Path = "/" + u.Path
+		}
+	}
+	return u.String()
+}
+
+// sarifColumns converts the byte columns of token.Positions to the code point
+// columns of SARIF regions, reading each file once.
+type sarifColumns struct {
+	lines map[string][]string
+}
+
+func (c *sarifColumns) region(start, end token.Position) sarifRegion {
+	region := sarifRegion{
+		StartLine:   start.Line,
+		StartColumn: c.column(start),
+		EndLine:     end.Line,
+		EndColumn:   c.column(end),
+	}
+	if runes := []rune(c.line(start)); start.Line == end.Line && region.StartColumn < region.EndColumn && region.EndColumn-1 <= len(runes) {
+		region.Snippet = &sarifMessage{string(runes[region.StartColumn-1 : region.EndColumn-1])}
+	}
+	return region
+}
+
+func (c *sarifColumns) line(pos token.Position) string {
+	lines, ok := c.lines[pos.Filename]
+	if !ok {
+		lines = readfile(pos.Filename)
+		c.lines[pos.Filename] = lines
+	}
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return ""
+	}
+	return lines[pos.Line-1]
+}
+
+// column returns the column of pos in code points, or in bytes if its line
+// can't be read.
+func (c *sarifColumns) column(pos token.Position) int {
+	line := c.line(pos)
+	if pos.Column < 1 || pos.Column-1 > len(line) {
+		return pos.Column
+	}
+	return utf8.RuneCountInString(line[:pos.Column-1]) + 1
+}
@@ -0,0 +1,117 @@
+package gettercheck
+
+import (
+	"bytes"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// TemplateFinding is the data format templates are executed with, once for
+// each unused getter error.
+type TemplateFinding struct {
+	// Pos is the position of the field, End the position right after the
+	// selector expression, and GetterPos the position of the getter's
+	// declaration. Each has Filename, Line and Column fields, and prints as
+	// file:line:column.
+	Pos, End, GetterPos token.Position
+	// Getter is the call that should replace the field, such as GetName().
+	Getter string
+	// Message describes the error, such as "unused getter: use GetName()
+	// instead of the Name field".
+	Message string
+	// Line is the line of source code the field is accessed on, trimmed.
+	Line string
+	// Package is the import path of the package the field is accessed in,
+	// and Function the function or method it is accessed in, if any.
+	Package, Function string
+	// Selector is the text of the selector expression, Receiver the type of
+	// the expression the field is selected from, and Field its name.
+	Selector, Receiver, Field string
+	// Reason explains why the getter can't be used as is, if it can't.
+	Reason string
+	// Builds lists the build contexts the error was found in, with -matrix.
+	Builds []string
+}
+
+// Templates are the named format templates. A newline is printed after each
+// finding.
+var Templates = map[string]string{
+	"default": `{{.Pos}}:	{{.Getter}}	{{.Line}}{{if .Reason}}	({{.Reason}}){{end}}{{if .Builds}}	[{{join .Builds " "}}]{{end}}`,
+	"verbose": `{{.Pos}}:	{{.Getter}}	{{.Line}}{{if .Reason}}	({{.Reason}}){{end}}{{if .Builds}}	[{{join .Builds " "}}]{{end}}
+	Getter at {{.GetterPos}}
+`,
+	// vim's default errorformat.
+	"vim": `{{.Pos.Filename}}:{{.Pos.Line}}:{{.Pos.Column}}: {{.Message}}`,
+	// Emacs's compilation mode, which highlights warnings.
+	"emacs": `{{.Pos.Filename}}:{{.Pos.Line}}:{{.Pos.Column}}: warning: {{.Message}}`,
+	// grep -n.
+	"grep": `{{.Pos.Filename}}:{{.Pos.Line}}:{{.Line}}`,
+}
+
+// TemplateNames returns the names of the named templates, sorted.
+func TemplateNames() []string {
+	names := make([]string, 0, len(Templates))
+	for name := range Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateFuncs are the functions available to format templates.
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"base": filepath.Base,
+}
+
+// ParseTemplate returns the named template text refers to, or parses text as
+// a template if it isn't one of Templates. Templates can use the join and
+// base functions, from the strings and path/filepath packages.
+func ParseTemplate(text string) (*template.Template, error) {
+	name := "format"
+	if named, ok := Templates[text]; ok {
+		name, text = text, named
+	}
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// NewTemplateFinding converts e to the data format templates are executed
+// with.
+func NewTemplateFinding(e UnusedGetterError) TemplateFinding {
+	return TemplateFinding{
+		Pos:       e.Pos,
+		End:       e.End,
+		GetterPos: e.GetterPos,
+		Getter:    e.FuncName,
+		Message:   e.message(),
+		Line:      strings.TrimSpace(e.Line),
+		Package:   e.Fingerprint.Package,
+		Function:  e.Fingerprint.Function,
+		Selector:  e.Fingerprint.Selector,
+		Receiver:  e.Receiver,
+		Field:     e.Field,
+		Reason:    e.Reason,
+		Builds:    e.Builds,
+	}
+}
+
+// WriteTemplate executes tmpl for each of the unused getter errors of r,
+// and writes the results to w, each followed by a newline.
+func WriteTemplate(w io.Writer, r Result, tmpl *template.Template) error {
+	var buf bytes.Buffer
+	for _, e := range r.UnusedGetterError {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, NewTemplateFinding(e)); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+// Package embedding embeds a message through an unexported field.
+package embedding
+
+import "github.com/saiskee/gettercheck/gettercheck/testdata/src/generated"
+
+type basic = generated.Basic
+
+// Public hides the getter of the message it embeds behind its own.
+type Public struct {
+	*basic
+}
+
+func (Public) GetName() string { return "" }
@@ -40,3 +40,14 @@ func (g *GrandParent) GetChild() *Parent {
 type ChildNoGetter struct {
 	Name string
 }
+
+type Optional struct {
+	Value *string
+}
+
+func (o *Optional) GetValue() string {
+	if o != nil && o.Value != nil {
+		return *o.Value
+	}
+	return ""
+}
@@ -0,0 +1,8 @@
+// Package thrift stands in for github.com/apache/thrift/lib/go/thrift.
+package thrift
+
+import "context"
+
+type TProtocol interface {
+	Flush(ctx context.Context) error
+}
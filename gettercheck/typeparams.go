@@ -0,0 +1,91 @@
+package gettercheck
+
+import "go/types"
+
+// coreType returns the underlying type of typ or, if typ is a type parameter,
+// the underlying type shared by all the types in its type set, or nil if
+// there is none.
+func coreType(typ types.Type) types.Type {
+	tparam, ok := typ.(*types.TypeParam)
+	if !ok {
+		return typ.Underlying()
+	}
+	iface, ok := tparam.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	terms, _ := constraintTerms(iface)
+	if len(terms) == 0 {
+		return nil
+	}
+	underlying := terms[0].Type().Underlying()
+	for _, term := range terms {
+		if !types.Identical(term.Type().Underlying(), underlying) {
+			return nil
+		}
+	}
+	return underlying
+}
+
+// constraintTerms returns the terms whose type set is that of iface: the
+// intersection of the type sets of the unions, types and interfaces embedded
+// in iface, recursively. Embedded interfaces without terms, such as those only
+// declaring methods, don't restrict it. restricted is false if nothing does.
+func constraintTerms(iface *types.Interface) (terms []*types.Term, restricted bool) {
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		var element []*types.Term
+		switch t := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			for j := 0; j < t.Len(); j++ {
+				element = append(element, t.Term(j))
+			}
+		default:
+			if embedded, ok := t.Underlying().(*types.Interface); ok {
+				if element, ok = constraintTerms(embedded); !ok {
+					continue
+				}
+			} else {
+				element = []*types.Term{types.NewTerm(false, t)}
+			}
+		}
+		if restricted {
+			terms = intersectTerms(terms, element)
+		} else {
+			terms, restricted = element, true
+		}
+	}
+	return terms, restricted
+}
+
+// intersectTerms returns the terms whose type set is the intersection of
+// the type sets of x and y.
+func intersectTerms(x, y []*types.Term) []*types.Term {
+	var terms []*types.Term
+	for _, a := range x {
+		for _, b := range y {
+			if term := intersectTerm(a, b); term != nil {
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
+
+// intersectTerm returns the term whose type set is the intersection of the
+// type sets of a and b, or nil if it is empty.
+func intersectTerm(a, b *types.Term) *types.Term {
+	if !a.Tilde() && !b.Tilde() {
+		if types.Identical(a.Type(), b.Type()) {
+			return a
+		}
+		return nil
+	}
+	if !types.Identical(a.Type().Underlying(), b.Type().Underlying()) {
+		return nil
+	}
+	// ~T and U intersect in U, the more specific of the two
+	if a.Tilde() {
+		return b
+	}
+	return a
+}
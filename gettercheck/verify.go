@@ -0,0 +1,174 @@
+package gettercheck
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// fileFindings are the findings in a single file.
+type fileFindings struct {
+	filename string
+	findings []finding
+}
+
+// edits returns the fixes for the file's fixable findings.
+func (f fileFindings) edits() []analysis.TextEdit {
+	var edits []analysis.TextEdit
+	for _, finding := range f.findings {
+		if finding.fixable() {
+			edits = append(edits, finding.edit())
+		}
+	}
+	return edits
+}
+
+// fixableOn reports whether any of the file's fixable findings is on line.
+func (f fileFindings) fixableOn(fset *token.FileSet, line int) bool {
+	for _, finding := range f.findings {
+		if finding.fixable() && fset.Position(finding.fieldPos).Line == line {
+			return true
+		}
+	}
+	return false
+}
+
+// reject gives the fixable findings on line, or on every line if line is 0,
+// a reason not to fix them.
+func (f fileFindings) reject(fset *token.FileSet, line int, reason string) {
+	for i := range f.findings {
+		if f.findings[i].fixable() && (line == 0 || fset.Position(f.findings[i].fieldPos).Line == line) {
+			f.findings[i].reason = reason
+		}
+	}
+}
+
+// rejectBrokenFixes type-checks pkg with the fixes for files applied in
+// memory, and rejects the fixes that introduce type errors. A new error is
+// first blamed on the fixes on the line it is reported at. If that doesn't
+// get rid of it, all the fixes in its file are rejected, and if that still
+// doesn't, all the fixes in the package.
+func (c *Checker) rejectBrokenFixes(pkg *packages.Package, files []fileFindings) {
+	known := make(map[string]bool)
+	for _, err := range pkg.Errors {
+		known[err.Msg] = true
+	}
+
+	for attempt := 0; ; attempt++ {
+		overlay := make(map[string][]byte)
+		for _, f := range files {
+			edits := f.edits()
+			if len(edits) == 0 {
+				continue
+			}
+			_, fixed, err := fixFile(pkg.Fset, f.filename, edits)
+			if err != nil {
+				f.reject(pkg.Fset, 0, fmt.Sprintf("fix rejected: %s", err))
+				continue
+			}
+			overlay[f.filename] = fixed
+		}
+		if len(overlay) == 0 {
+			return
+		}
+
+		errs, err := c.typeErrors(pkg, overlay)
+		if err != nil {
+			for _, f := range files {
+				f.reject(pkg.Fset, 0, fmt.Sprintf("fix rejected: could not type-check it: %s", err))
+			}
+			return
+		}
+
+		type rejection struct {
+			file   fileFindings
+			line   int
+			reason string
+		}
+		var rejections []rejection
+		for _, e := range errs {
+			if known[e.Msg] {
+				continue
+			}
+			reason := "fix rejected: " + e.Msg
+			filename, line := errorPosition(e)
+			var file *fileFindings
+			for i := range files {
+				if files[i].filename == filename {
+					file = &files[i]
+				}
+			}
+			switch {
+			case file == nil || attempt >= 2:
+				for _, f := range files {
+					rejections = append(rejections, rejection{f, 0, reason})
+				}
+			case attempt == 0 && file.fixableOn(pkg.Fset, line):
+				rejections = append(rejections, rejection{*file, line, reason})
+			default:
+				rejections = append(rejections, rejection{*file, 0, reason})
+			}
+		}
+		if len(rejections) == 0 {
+			return
+		}
+		for _, r := range rejections {
+			r.file.reject(pkg.Fset, r.line, r.reason)
+		}
+	}
+}
+
+// typeErrors reloads pkg with the contents of its files replaced by overlay,
+// and returns the errors found.
+func (c *Checker) typeErrors(pkg *packages.Package, overlay map[string][]byte) ([]packages.Error, error) {
+	if len(pkg.GoFiles) == 0 {
+		return nil, fmt.Errorf("package %s has no files", pkg.ID)
+	}
+	cfg := c.packagesConfig(packages.LoadSyntax)
+	cfg.Overlay = overlay
+	pkgs, err := loadPackages(cfg, "file="+pkg.GoFiles[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pkgs {
+		if p.ID != pkg.ID {
+			continue
+		}
+		// The go command reports the same errors when compiling the package
+		// for its export data, but at copies of the overlaid files.
+		var errs []packages.Error
+		for _, e := range p.Errors {
+			if e.Kind != packages.ListError {
+				errs = append(errs, e)
+			}
+		}
+		return errs, nil
+	}
+	return nil, fmt.Errorf("package %s not found", pkg.ID)
+}
+
+// errorPosition returns the file and line of err, whose position has the
+// form file:line:column or file:line. line is 0 if it can't be determined.
+func errorPosition(err packages.Error) (filename string, line int) {
+	filename = err.Pos
+	var numbers []int
+	for len(numbers) < 2 {
+		i := strings.LastIndex(filename, ":")
+		if i < 0 {
+			break
+		}
+		n, convErr := strconv.Atoi(filename[i+1:])
+		if convErr != nil {
+			break
+		}
+		filename, numbers = filename[:i], append(numbers, n)
+	}
+	if len(numbers) > 0 {
+		line = numbers[len(numbers)-1]
+	}
+	return filename, line
+}
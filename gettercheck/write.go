@@ -0,0 +1,87 @@
+package gettercheck
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// fixFile applies edits to the file filename, as it was loaded into fset, and
+// returns its original and fixed contents. Only the edited byte ranges change,
+// the rest of the file is kept as is.
+func fixFile(fset *token.FileSet, filename string, edits []analysis.TextEdit) (src, fixed []byte, err error) {
+	src, err = ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	fixed, err = applyEdits(fset, src, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, fixed, nil
+}
+
+// applyEdits applies edits, which must all be within the same file of fset,
+// to src, the contents of that file.
+func applyEdits(fset *token.FileSet, src []byte, edits []analysis.TextEdit) ([]byte, error) {
+	if len(edits) == 0 {
+		return src, nil
+	}
+	file := fset.File(edits[0].Pos)
+	if file == nil || file.Size() != len(src) {
+		return nil, fmt.Errorf("file changed since it was loaded")
+	}
+	sorted := make([]analysis.TextEdit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Pos < sorted[j].Pos })
+
+	var buf bytes.Buffer
+	last := 0
+	for _, edit := range sorted {
+		start, end := file.Offset(edit.Pos), file.Offset(edit.End)
+		if start < last {
+			return nil, fmt.Errorf("overlapping edits at offset %d", start)
+		}
+		buf.Write(src[last:start])
+		buf.Write(edit.NewText)
+		last = end
+	}
+	buf.Write(src[last:])
+	return buf.Bytes(), nil
+}
+
+// writeFile replaces the contents of the existing file filename with data.
+// The data is written to a temporary file in the same directory, which is then
+// renamed over the original, so the file is never left partially written.
+// The mode of the original file is preserved.
+func writeFile(filename string, data []byte) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	// Removing the temporary file fails harmlessly once it has been renamed.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
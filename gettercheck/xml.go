@@ -0,0 +1,150 @@
+package gettercheck
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// WriteCheckstyle writes r to w as a checkstyle XML report, with the unused
+// getter and directive errors grouped by file.
+func WriteCheckstyle(w io.Writer, r Result) error {
+	files := make(map[string]*checkstyleFile)
+	file := func(name string) *checkstyleFile {
+		f, ok := files[name]
+		if !ok {
+			f = &checkstyleFile{Name: name}
+			files[name] = f
+		}
+		return f
+	}
+	for _, e := range r.UnusedGetterError {
+		f := file(e.Pos.Filename)
+		f.Errors = append(f.Errors, checkstyleError{
+			Line:     e.Pos.Line,
+			Column:   e.Pos.Column,
+			Severity: "warning",
+			Message:  e.message(),
+			Source:   "gettercheck." + RuleUnusedGetter,
+		})
+	}
+	for _, e := range r.DirectiveErrors {
+		f := file(e.Pos.Filename)
+		f.Errors = append(f.Errors, checkstyleError{
+			Line:     e.Pos.Line,
+			Column:   e.Pos.Column,
+			Severity: "warning",
+			Message:  fmt.Sprintf("%s: %s", e.Text, e.Problem),
+			Source:   "gettercheck." + RuleUnusedDirective,
+		})
+	}
+
+	report := checkstyleReport{Version: "5.0"}
+	for _, f := range files {
+		sort.SliceStable(f.Errors, func(i, j int) bool {
+			if f.Errors[i].Line != f.Errors[j].Line {
+				return f.Errors[i].Line < f.Errors[j].Line
+			}
+			return f.Errors[i].Column < f.Errors[j].Column
+		})
+		report.Files = append(report.Files, *f)
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Name < report.Files[j].Name })
+	return writeXML(w, report)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit writes r to w as a JUnit XML report, with a test case for each
+// package that was checked. The test case of a package fails if it has unused
+// getter or directive errors, and lists them.
+func WriteJUnit(w io.Writer, r Result) error {
+	failures := make(map[string][]string)
+	packages := append([]string(nil), r.Packages...)
+	for _, e := range r.UnusedGetterError {
+		failures[e.Fingerprint.Package] = append(failures[e.Fingerprint.Package],
+			fmt.Sprintf("%s: %s\n\t%s", e.Pos, e.message(), strings.TrimSpace(e.Line)))
+		packages = append(packages, e.Fingerprint.Package)
+	}
+	for _, e := range r.DirectiveErrors {
+		failures[e.Package] = append(failures[e.Package], fmt.Sprintf("%s: %s: %s", e.Pos, e.Text, e.Problem))
+		packages = append(packages, e.Package)
+	}
+
+	suite := junitTestSuite{Name: "gettercheck"}
+	for _, pkg := range uniqueStrings(packages) {
+		testCase := junitTestCase{ClassName: "gettercheck", Name: pkg}
+		if problems := failures[pkg]; len(problems) > 0 {
+			message := fmt.Sprintf("%d problems found", len(problems))
+			if len(problems) == 1 {
+				message = "1 problem found"
+			}
+			testCase.Failure = &junitFailure{
+				Message: message,
+				Type:    "gettercheck",
+				Text:    strings.Join(problems, "\n"),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+	}
+	return writeXML(w, junitTestSuites{Suites: []junitTestSuite{suite}})
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
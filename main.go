@@ -4,14 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"github.com/saiskee/gettercheck/gettercheck"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
-	"runtime"
+	"sort"
 	"strings"
-	"sync"
-
-	"golang.org/x/tools/go/packages"
+	"text/template"
 )
 
 const (
@@ -20,69 +18,112 @@ const (
 	exitFatalError
 )
 
-type ignoreFlag map[string]*regexp.Regexp
-
 // global flags
 var (
 	abspath bool
 	verbose bool
+	matrix  gettercheck.BuildContexts
+
+	baselinePath      string
+	baselineWritePath string
+	diffFilterPath    string
+
+	// format is the output format, one of formats.
+	format string
+	// formatTemplate is the template the text format prints findings with.
+	formatTemplate *template.Template
 )
 
-func (f ignoreFlag) String() string {
-	pairs := make([]string, 0, len(f))
-	for pkg, re := range f {
-		prefix := ""
-		if pkg != "" {
-			prefix = pkg + ":"
-		}
-		pairs = append(pairs, prefix+re.String())
+// formats report the unused getter errors in each of the output formats.
+var formats = map[string]func(gettercheck.Result) error{
+	"text": reportWith(func(w io.Writer, e gettercheck.Result) error {
+		return gettercheck.WriteTemplate(w, e, formatTemplate)
+	}),
+	"json":       reportWith(gettercheck.WriteJSON),
+	"jsonl":      reportWith(gettercheck.WriteJSONLines),
+	"sarif":      reportWith(gettercheck.WriteSARIF),
+	"checkstyle": reportWith(gettercheck.WriteCheckstyle),
+	"junit":      reportWith(gettercheck.WriteJUnit),
+	"github":     reportWith(gettercheck.WriteGitHub),
+	"rdjson":     reportWith(gettercheck.WriteRDJSON),
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
 	}
-	return fmt.Sprintf("%q", strings.Join(pairs, ","))
+	sort.Strings(names)
+	return names
 }
 
-func (f ignoreFlag) Set(s string) error {
-	if s == "" {
-		return nil
+func reportDiffs(e gettercheck.Result) {
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = ""
 	}
-	for _, pair := range strings.Split(s, ",") {
-		colonIndex := strings.Index(pair, ":")
-		var pkg, re string
-		if colonIndex == -1 {
-			pkg = ""
-			re = pair
-		} else {
-			pkg = pair[:colonIndex]
-			re = pair[colonIndex+1:]
+	for _, diff := range e.Diffs {
+		if diff.Hunks == "" {
+			continue
 		}
-		regex, err := regexp.Compile(re)
-		if err != nil {
-			return err
+		name := diff.Filename
+		if newName, err := filepath.Rel(wd, name); err == nil {
+			name = newName
 		}
-		f[pkg] = regex
+		name = filepath.ToSlash(name)
+		fmt.Printf("--- a/%s\n+++ b/%s\n%s", name, name, diff.Hunks)
 	}
-	return nil
 }
 
+// reportWith returns a function reporting a result with write, with paths
+// relative to the working directory unless -abspath is set.
+func reportWith(write func(io.Writer, gettercheck.Result) error) func(gettercheck.Result) error {
+	return func(e gettercheck.Result) error {
+		errs := make([]gettercheck.UnusedGetterError, len(e.UnusedGetterError))
+		for i, err := range e.UnusedGetterError {
+			err.Pos.Filename = relPath(err.Pos.Filename)
+			err.End.Filename = relPath(err.End.Filename)
+			errs[i] = err
+		}
+		e.UnusedGetterError = errs
+		directiveErrors := make([]gettercheck.DirectiveError, len(e.DirectiveErrors))
+		for i, err := range e.DirectiveErrors {
+			err.Pos.Filename = relPath(err.Pos.Filename)
+			directiveErrors[i] = err
+		}
+		e.DirectiveErrors = directiveErrors
+		return write(os.Stdout, e)
+	}
+}
 
-func reportResult(e gettercheck.Result) {
+// relPath returns filename relative to the working directory, unless
+// -abspath is set or it can't be.
+func relPath(filename string) string {
+	if abspath {
+		return filename
+	}
 	wd, err := os.Getwd()
 	if err != nil {
-		wd = ""
+		return filename
 	}
-	for _, unusedGetterError := range e.UnusedGetterError {
-		pos := unusedGetterError.Pos.String()
-		if !abspath {
-			newPos, err := filepath.Rel(wd, pos)
-			if err == nil {
-				pos = newPos
-			}
-		}
-		// Print result to stdout
-		if verbose {
-			fmt.Printf("%s:\t%s\t%s\n\tGetter at %s\n\n", pos, unusedGetterError.FuncName, unusedGetterError.Line, unusedGetterError.GetterPos.String())
-		}else {
-			fmt.Printf("%s:\t%s\t%s\n", pos, unusedGetterError.FuncName, unusedGetterError.Line)
-		}
+	rel, err := filepath.Rel(wd, filename)
+	if err != nil {
+		return filename
+	}
+	return rel
+}
+
+func reportDirectives(w io.Writer, e gettercheck.Result) {
+	for _, directiveError := range e.DirectiveErrors {
+		pos := directiveError.Pos
+		pos.Filename = relPath(pos.Filename)
+		fmt.Fprintf(w, "%s:\t%s\t%s\n", pos, directiveError.Text, directiveError.Problem)
+	}
+}
+
+func reportModified(e gettercheck.Result) {
+	for _, file := range e.ModifiedFiles {
+		fmt.Fprintf(os.Stderr, "wrote %s\n", file)
 	}
 }
 
@@ -93,13 +134,51 @@ func logf(msg string, args ...interface{}) {
 }
 
 func mainCmd(args []string) int {
+	if len(args) > 1 && args[1] == "precommit" {
+		return precommitCmd(args[1:])
+	}
 	var checker gettercheck.Checker
 	paths, rc := parseFlags(&checker, args)
 	if rc != exitCodeOk {
 		return rc
 	}
+	return runCheck(&checker, func() (gettercheck.Result, error) {
+		return checkMatrix(&checker, paths...)
+	})
+}
+
+// runCheck runs check, then filters and reports its result as the flags
+// require, and returns the exit code.
+func runCheck(checker *gettercheck.Checker, check func() (gettercheck.Result, error)) int {
+	rc := exitCodeOk
+	// Read the changes to restrict the findings to before checking, in case
+	// they are invalid. The findings outside of them are neither fixed nor
+	// reported, unless a baseline is being recorded.
+	if diffFilterPath != "" {
+		changed, err := readChangedLines(diffFilterPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to read diff: %s\n", err)
+			return exitFatalError
+		}
+		if baselineWritePath == "" {
+			checker.ChangedLines = changed
+		}
+	}
+	// Read the baseline before checking, in case it is invalid. The findings
+	// it accepts are neither fixed nor reported, unless a new baseline is
+	// being recorded.
+	if baselinePath != "" {
+		baseline, err := gettercheck.ReadBaseline(baselinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to read baseline: %s\n", err)
+			return exitFatalError
+		}
+		if baselineWritePath == "" {
+			checker.Baseline = baseline
+		}
+	}
 	// Check paths
-	result, err := checkPaths(&checker, paths...)
+	result, err := check()
 	if err != nil {
 		if err == gettercheck.ErrNoGoFiles {
 			fmt.Fprintln(os.Stderr, err)
@@ -108,14 +187,61 @@ func mainCmd(args []string) int {
 		fmt.Fprintf(os.Stderr, "error: failed to check packages: %s\n", err)
 		return exitFatalError
 	}
-	// Report unused getter error if errors are found
-	if len(result.UnusedGetterError) > 0 {
-		reportResult(result)
-		if !checker.WriteGetters{
-			return exitUncheckedError
+	// Recording a baseline accepts all the current findings
+	if baselineWritePath != "" {
+		if err := gettercheck.NewBaseline(result.UnusedGetterError).WriteFile(baselineWritePath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to write baseline: %s\n", err)
+			return exitFatalError
+		}
+		logf("wrote %d findings to %s", len(result.UnusedGetterError), baselineWritePath)
+		return exitCodeOk
+	}
+	// In diff mode only the diffs are printed, so they can be applied
+	if checker.DiffGetters {
+		reportDiffs(result)
+		if len(result.Diffs) > 0 {
+			rc = exitUncheckedError
+		}
+	} else {
+		// Report unused getter errors in the selected format, which
+		// machine-readable formats do even if there are none
+		if err := formats[format](result); err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to report findings: %s\n", err)
+			return exitFatalError
+		}
+		reportModified(result)
+		if len(result.UnusedGetterError) > 0 && !checker.WriteGetters {
+			rc = exitUncheckedError
+		}
+	}
+	// Directives are reported after the findings, but not alongside diffs,
+	// and only on stdout in the text format
+	if !checker.DiffGetters && len(result.DirectiveErrors) > 0 {
+		if format == "text" {
+			reportDirectives(os.Stdout, result)
+		} else {
+			reportDirectives(os.Stderr, result)
+		}
+		rc = exitUncheckedError
+	}
+	// Files that couldn't be fixed are reported after the findings
+	if len(result.FileErrors) > 0 {
+		for _, fileError := range result.FileErrors {
+			fmt.Fprintf(os.Stderr, "error: failed to fix %s\n", fileError)
 		}
+		return exitFatalError
 	}
-	return exitCodeOk
+	return rc
+}
+
+// checkMatrix checks paths under each of the build contexts in the matrix,
+// if any, and merges the results.
+func checkMatrix(c *gettercheck.Checker, paths ...string) (gettercheck.Result, error) {
+	if len(matrix) == 0 {
+		return checkPaths(c, paths...)
+	}
+	logf("checking under %s", &matrix)
+	return c.CheckMatrix(matrix, paths...)
 }
 
 func checkPaths(c *gettercheck.Checker, paths ...string) (gettercheck.Result, error) {
@@ -124,52 +250,122 @@ func checkPaths(c *gettercheck.Checker, paths ...string) (gettercheck.Result, er
 		return gettercheck.Result{}, err
 	}
 	// Check for errors in the initial packages.
-	work := make(chan *packages.Package, len(pkgs))
 	for _, pkg := range pkgs {
 		if len(pkg.Errors) > 0 {
 			return gettercheck.Result{}, fmt.Errorf("errors while loading package %s: %v", pkg.ID, pkg.Errors)
 		}
-		work <- pkg
+		logf("checking %s", pkg.ID)
 	}
-	close(work)
-
-	var wg sync.WaitGroup
-	result := &gettercheck.Result{}
-	mu := &sync.Mutex{}
-	for i := 0; i < runtime.NumCPU(); i++ {
-		wg.Add(1)
-
-		go func() {
-			defer wg.Done()
-			for pkg := range work {
-				logf("checking %s", pkg.Types.Path())
-				r := c.CheckPackage(pkg)
-				mu.Lock()
-				result.Append(r)
-				mu.Unlock()
-			}
-		}()
-	}
-
-	wg.Wait()
-	return result.Unique(), nil
+	return c.CheckPackages(pkgs), nil
 }
 
 func parseFlags(checker *gettercheck.Checker, args []string) ([]string, int) {
+	format = "text"
+	templateText := ""
 	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
 
-	flags.BoolVar(&checker.Exclusions.TestFiles, "ignoretests", false, "if true, checking of _test.go files is disabled")
-	flags.BoolVar(&checker.Exclusions.GeneratedFiles, "ignoregenerated", false, "if true, checking of files with generated code is disabled")
+	configPath := flags.String("config", "", "configuration file to use instead of the "+gettercheck.ConfigFile+" file of the module")
+	flags.BoolVar(&checker.Exclusions.TestFiles, "ignoretests", checker.Exclusions.TestFiles, "if true, checking of _test.go files is disabled")
+	flags.BoolVar(&checker.Exclusions.GeneratedFiles, "ignoregenerated", checker.Exclusions.GeneratedFiles, "if true, checking of files with generated code is disabled")
+	flags.Var(&checker.Exclusions.Ignore, "ignore", "comma separated list of pkg:regex pairs; findings for fields whose Message.Field name matches the regex in packages matching pkg are ignored")
 	flags.BoolVar(&checker.WriteGetters, "write", false, "if true, overwrites found non-getter accessors with getters")
+	flags.BoolVar(&checker.DiffGetters, "diff", false, "if true, prints the changes -write would make as a unified diff")
+	flags.BoolVar(&checker.UnusedDirectives, "unused-directives", checker.UnusedDirectives, "if true, reports //gettercheck: directives that are malformed, expired or don't suppress anything")
+	if len(checker.Profiles) == 0 {
+		checker.Profiles = gettercheck.DefaultProfiles()
+	}
+	flags.Var(&checker.Profiles, "profiles", "comma separated list of generator profiles to check: "+strings.Join(gettercheck.ProfileNames(), ", "))
+
+	flags.StringVar(&baselinePath, "baseline", "", "file of known findings written by -baseline-write; only new findings are reported")
+	flags.StringVar(&baselineWritePath, "baseline-write", "", "file to record the current findings to, as a baseline for -baseline")
+
+	flags.StringVar(&format, "format", format, "output format: "+strings.Join(formatNames(), ", "))
+	flags.StringVar(&templateText, "format-template", templateText, "Go template to print each finding with in the text format, or one of the named templates: "+strings.Join(gettercheck.TemplateNames(), ", "))
+	flags.StringVar(&diffFilterPath, "diff-filter", "", "unified diff file, or - for stdin, such as the output of git diff; only findings on the lines it adds or modifies are reported")
 
 	flags.BoolVar(&verbose, "verbose", false, "produce more verbose logging")
 	flags.BoolVar(&abspath, "abspath", false, "print absolute paths to files")
 
 	flags.StringVar(&checker.Mod, "mod", "", "module download mode to use: readonly or vendor. See 'go help modules' for more.")
+	flags.Var(&matrix, "matrix", "comma separated list of goos/goarch[:tag+...] build contexts to check packages under, merging the results")
+	tags := flags.String("tags", "", "comma or space separated list of build tags to consider satisfied")
+	flags.StringVar(&checker.Build.GOOS, "goos", "", "target operating system to load packages for, instead of $GOOS")
+	flags.StringVar(&checker.Build.GOARCH, "goarch", "", "target architecture to load packages for, instead of $GOARCH")
+	flags.StringVar(&checker.Build.CGOEnabled, "cgo", "", "0 or 1 to disable or enable cgo, instead of $CGO_ENABLED")
+
+	// The configuration provides the defaults of the flags, so the flags
+	// are parsed twice: first to find the configuration file only, then on
+	// top of the configuration. Errors are reported by the second parse.
+	configFlags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	configFlags.SetOutput(io.Discard)
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name != "config" {
+			configFlags.Var(ignoredFlag{f.Value}, f.Name, f.Usage)
+		}
+	})
+	configFlags.StringVar(configPath, "config", "", "")
+	_ = configFlags.Parse(args[1:])
+
+	config, err := gettercheck.LoadConfig(*configPath, ".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: failed to load configuration: %s\n", err)
+		return nil, exitFatalError
+	}
+	if config != nil {
+		if err := config.Apply(checker); err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to load configuration: %s\n", err)
+			return nil, exitFatalError
+		}
+		if config.Format != "" {
+			format = config.Format
+		}
+		if config.FormatTemplate != "" {
+			templateText = config.FormatTemplate
+		}
+	}
 
 	if err := flags.Parse(args[1:]); err != nil {
 		return nil, exitFatalError
 	}
+	checker.Build.Tags = strings.FieldsFunc(*tags, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if _, ok := formats[format]; !ok {
+		fmt.Fprintf(os.Stderr, "unknown format %q: must be one of %s\n", format, strings.Join(formatNames(), ", "))
+		return nil, exitFatalError
+	}
+	// A template from the configuration only applies to the text format
+	if format != "text" {
+		templateSet := false
+		flags.Visit(func(f *flag.Flag) {
+			templateSet = templateSet || f.Name == "format-template"
+		})
+		if templateSet {
+			fmt.Fprintf(os.Stderr, "-format-template can only be used with the text format\n")
+			return nil, exitFatalError
+		}
+		templateText = ""
+	}
+	if templateText == "" {
+		templateText = "default"
+		if verbose {
+			templateText = "verbose"
+		}
+	}
+	formatTemplate, err = gettercheck.ParseTemplate(templateText)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid value for flag -format-template: %s\n", err)
+		return nil, exitFatalError
+	}
+	if cgo := checker.Build.CGOEnabled; cgo != "" && cgo != "0" && cgo != "1" {
+		fmt.Fprintf(os.Stderr, "invalid value %q for flag -cgo: must be 0 or 1\n", cgo)
+		return nil, exitFatalError
+	}
+	// Each build context would print its own diff of the same files
+	if checker.DiffGetters && len(matrix) > 0 {
+		fmt.Fprintf(os.Stderr, "-diff can't be used with -matrix\n")
+		return nil, exitFatalError
+	}
 
 	paths := flags.Args()
 	if len(paths) == 0 {
@@ -179,6 +375,50 @@ func parseFlags(checker *gettercheck.Checker, args []string) ([]string, int) {
 	return paths, exitCodeOk
 }
 
+// readChangedLines reads the unified diff in filename, or stdin if it is -.
+// The paths in the diff are relative to the root of the git repository the
+// working directory is in, if any, like those git diff prints.
+func readChangedLines(filename string) (gettercheck.ChangedLines, error) {
+	r := os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	for root := dir; ; root = filepath.Dir(root) {
+		if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+			dir = root
+			break
+		}
+		if filepath.Dir(root) == root {
+			break
+		}
+	}
+	return gettercheck.ParseUnifiedDiff(r, dir)
+}
+
+// ignoredFlag stands for a flag whose value is ignored, while still telling
+// the flag package whether the flag it stands for is boolean.
+type ignoredFlag struct {
+	flag.Value
+}
+
+func (f ignoredFlag) Set(string) error {
+	return nil
+}
+
+func (f ignoredFlag) IsBoolFlag() bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
 func main() {
 	os.Exit(mainCmd(os.Args))
 }
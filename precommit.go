@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/saiskee/gettercheck/gettercheck"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// precommitCmd checks the Go files staged in the git repository the working
+// directory is in, as they are in the index rather than in the working tree,
+// and only reports the findings in them. The arguments are flags followed by
+// pathspecs restricting the staged files to check.
+func precommitCmd(args []string) int {
+	var checker gettercheck.Checker
+	pathspecs, rc := parseFlags(&checker, args)
+	if rc != exitCodeOk {
+		return rc
+	}
+	// The index content can't be written back to the working tree
+	if checker.WriteGetters || checker.DiffGetters || baselineWritePath != "" {
+		fmt.Fprintf(os.Stderr, "-write, -diff and -baseline-write can't be used with precommit\n")
+		return exitFatalError
+	}
+	index, staged, err := indexFiles(pathspecs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: failed to read staged files: %s\n", err)
+		return exitFatalError
+	}
+	if len(staged) == 0 {
+		logf("no staged Go files")
+		return runCheck(&checker, func() (gettercheck.Result, error) {
+			return gettercheck.Result{}, nil
+		})
+	}
+	checker.Overlay = index
+
+	// Only the packages containing staged files are loaded
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, filename := range staged {
+		dir := filepath.Dir(filename)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return runCheck(&checker, func() (gettercheck.Result, error) {
+		result, err := checkMatrix(&checker, dirs...)
+		if err != nil {
+			return result, err
+		}
+		return onlyFiles(result, staged), nil
+	})
+}
+
+// ignoredFile is overlaid on the Go files that are in the working tree but not
+// in the index, so that they are left out of their package.
+var ignoredFile = []byte("//go:build ignore\n\npackage ignored\n")
+
+// indexFiles returns the Go files of the directories containing Go files
+// staged in the git repository the working directory is in, restricted to
+// pathspecs, as they are in the index. The files are returned by absolute
+// filename, along with the staged files that weren't deleted among them.
+// Files that are in the working tree but not in the index, because they are
+// untracked or their deletion is staged, are returned as ignoredFile.
+func indexFiles(pathspecs []string) (index map[string][]byte, staged []string, err error) {
+	// The root of the repository is found from the working directory rather
+	// than with --show-toplevel, which evaluates symbolic links, so that
+	// filenames are the same as those the go command prints.
+	out, err := git("", "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	// The prefix is the working directory relative to the root, such as a/b/
+	if prefix := strings.Trim(strings.TrimSpace(string(out)), "/"); prefix != "" {
+		for range strings.Split(prefix, "/") {
+			root = filepath.Dir(root)
+		}
+	}
+	// The names git diff prints are relative to the root of the repository,
+	// and renames are listed as a deletion and an addition.
+	out, err = git("", append([]string{"diff", "--cached", "--name-status", "-z", "--no-renames", "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var dirs []string
+	seen := make(map[string]bool)
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, name := fields[i], fields[i+1]
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if status != "D" {
+			staged = append(staged, filepath.Join(root, filepath.FromSlash(name)))
+		}
+		if dir := path.Dir(name); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	index = make(map[string][]byte)
+	for _, dir := range dirs {
+		files, err := indexDir(root, dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		for filename, src := range files {
+			index[filename] = src
+		}
+	}
+	return index, staged, nil
+}
+
+// indexDir returns the Go files directly in dir, relative to the root of the
+// repository, as they are in the index, and the other Go files in dir in the
+// working tree as ignoredFile.
+func indexDir(root, dir string) (map[string][]byte, error) {
+	// Each line is the mode, object name and stage of a file, then its name
+	out, err := git(root, "ls-files", "-s", "-z", "--", dir+"/")
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for _, line := range strings.Split(string(out), "\x00") {
+		tab := strings.IndexByte(line, '\t')
+		if tab < 0 {
+			continue
+		}
+		info, name := strings.Fields(line[:tab]), line[tab+1:]
+		if path.Dir(name) != dir || !strings.HasSuffix(name, ".go") || !strings.HasPrefix(info[0], "100") {
+			continue
+		}
+		src, err := git(root, "cat-file", "blob", info[1])
+		if err != nil {
+			return nil, err
+		}
+		files[filepath.Join(root, filepath.FromSlash(name))] = src
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		filename := filepath.Join(root, filepath.FromSlash(dir), entry.Name())
+		if _, ok := files[filename]; !ok && !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+			files[filename] = ignoredFile
+		}
+	}
+	return files, nil
+}
+
+// git runs git with args in dir, or in the working directory if dir is empty,
+// and returns its output.
+func git(dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// onlyFiles returns r without the unused getter and directive errors outside
+// of files. Filenames are compared once their symbolic links are evaluated.
+func onlyFiles(r gettercheck.Result, files []string) gettercheck.Result {
+	kept := make(map[string]bool)
+	for _, filename := range files {
+		kept[evalSymlinks(filename)] = true
+	}
+	filtered := r
+	filtered.UnusedGetterError = nil
+	for _, err := range r.UnusedGetterError {
+		if kept[evalSymlinks(err.Pos.Filename)] {
+			filtered.UnusedGetterError = append(filtered.UnusedGetterError, err)
+		}
+	}
+	filtered.DirectiveErrors = nil
+	for _, err := range r.DirectiveErrors {
+		if kept[evalSymlinks(err.Pos.Filename)] {
+			filtered.DirectiveErrors = append(filtered.DirectiveErrors, err)
+		}
+	}
+	return filtered
+}
+
+// evalSymlinks returns filename with its symbolic links evaluated, or cleaned
+// if they can't be, such as when it doesn't exist in the working tree.
+func evalSymlinks(filename string) string {
+	if evaluated, err := filepath.EvalSymlinks(filename); err == nil {
+		return evaluated
+	}
+	return filepath.Clean(filename)
+}
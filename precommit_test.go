@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/saiskee/gettercheck/gettercheck"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const message = `package pb
+
+type Msg struct{ Name string }
+
+func (*Msg) ProtoMessage() {}
+
+func (m *Msg) GetName() string {
+	if m == nil {
+		return ""
+	}
+	return m.Name
+}
+`
+
+var _ = Describe("precommit", func(){
+	var (
+		root string
+		wd   string
+	)
+
+	Git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=gettercheck", "-c", "user.email=gettercheck@example.com"}, args...)...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		ExpectWithOffset(1, err).NotTo(HaveOccurred(), string(out))
+	}
+
+	WriteFile := func(name, src string) {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		ExpectWithOffset(1, os.MkdirAll(filepath.Dir(filename), 0755)).To(Succeed())
+		ExpectWithOffset(1, ioutil.WriteFile(filename, []byte(src), 0644)).To(Succeed())
+	}
+
+	// Precommit runs the precommit command in dir, relative to the root of
+	// the repository, and returns its exit code and the findings it reports.
+	Precommit := func(dir string, args ...string) (int, []gettercheck.JSONFinding) {
+		ExpectWithOffset(1, os.Chdir(dir)).To(Succeed())
+		out, err := ioutil.TempFile("", "precommit")
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		defer os.Remove(out.Name())
+		defer out.Close()
+		stdout := os.Stdout
+		os.Stdout = out
+		rc := precommitCmd(append([]string{"precommit", "-format=json"}, args...))
+		os.Stdout = stdout
+
+		contents, err := ioutil.ReadFile(out.Name())
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		var report gettercheck.JSONReport
+		ExpectWithOffset(1, json.Unmarshal(contents, &report)).To(Succeed(), string(contents))
+		return rc, report.Findings
+	}
+
+	Files := func(findings []gettercheck.JSONFinding) []string {
+		var files []string
+		for _, f := range findings {
+			files = append(files, f.File)
+		}
+		return files
+	}
+
+	BeforeEach(func(){
+		var err error
+		wd, err = os.Getwd()
+		Expect(err).NotTo(HaveOccurred())
+		root, err = ioutil.TempDir("", "precommit")
+		Expect(err).NotTo(HaveOccurred())
+
+		WriteFile("go.mod", "module example.com/precommit\n\ngo 1.22\n")
+		WriteFile("pb/pb.go", message)
+		WriteFile("app/a.go", `package app
+
+import "example.com/precommit/pb"
+
+func A(m *pb.Msg) string {
+	return m.GetName()
+}
+`)
+		WriteFile("app/b.go", `package app
+
+import "example.com/precommit/pb"
+
+func B(m *pb.Msg) string {
+	return m.GetName()
+}
+`)
+		Git("init", "-q")
+		Git("add", ".")
+		Git("commit", "-q", "-m", "init")
+	})
+
+	AfterEach(func(){
+		Expect(os.Chdir(wd)).To(Succeed())
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	It("reports nothing when no Go files are staged", func(){
+		WriteFile("app/a.go", "package app\n\nfunc A() {}\n")
+		rc, findings := Precommit(root)
+		Expect(rc).To(Equal(exitCodeOk))
+		Expect(findings).To(BeEmpty())
+	})
+
+	It("checks the staged content of staged files only", func(){
+		WriteFile("app/a.go", `package app
+
+import "example.com/precommit/pb"
+
+func A(m *pb.Msg) string {
+	return m.Name
+}
+`)
+		Git("add", "app/a.go")
+		// Neither the unstaged fix of the staged file nor the unstaged
+		// finding in the other file are checked
+		WriteFile("app/a.go", `package app
+
+import "example.com/precommit/pb"
+
+func A(m *pb.Msg) string {
+	return m.GetName()
+}
+`)
+		WriteFile("app/b.go", `package app
+
+import "example.com/precommit/pb"
+
+func B(m *pb.Msg) string {
+	return m.Name
+}
+`)
+		rc, findings := Precommit(root)
+		Expect(rc).To(Equal(exitUncheckedError))
+		Expect(Files(findings)).To(Equal([]string{"app/a.go"}))
+		Expect(findings[0].Source).To(Equal("return m.Name"))
+	})
+
+	It("leaves untracked files and files whose deletion is staged out of their package", func(){
+		// Both would redeclare A if they were loaded
+		WriteFile("app/a.go", `package app
+
+import "example.com/precommit/pb"
+
+func A(m *pb.Msg) string {
+	return m.Name
+}
+
+func B(m *pb.Msg) string {
+	return m.GetName()
+}
+`)
+		Git("add", "app/a.go")
+		Git("rm", "-q", "--cached", "app/b.go")
+		WriteFile("app/c.go", `package app
+
+import "example.com/precommit/pb"
+
+func A(m *pb.Msg) string {
+	return m.Name
+}
+`)
+		rc, findings := Precommit(root)
+		Expect(rc).To(Equal(exitUncheckedError))
+		Expect(Files(findings)).To(Equal([]string{"app/a.go"}))
+	})
+
+	It("finds the root of the repository from subdirectories reached through symbolic links", func(){
+		WriteFile("app/a.go", `package app
+
+import "example.com/precommit/pb"
+
+func A(m *pb.Msg) string {
+	return m.Name
+}
+`)
+		Git("add", "app/a.go")
+		link := root + "-link"
+		Expect(os.Symlink(root, link)).To(Succeed())
+		defer os.Remove(link)
+
+		rc, findings := Precommit(filepath.Join(link, "app"))
+		Expect(rc).To(Equal(exitUncheckedError))
+		Expect(Files(findings)).To(Equal([]string{"a.go"}))
+	})
+
+	It("restricts the staged files to the pathspecs", func(){
+		WriteFile("pb/pb.go", message+`
+func Name(m *Msg) string {
+	return m.Name
+}
+`)
+		WriteFile("app/a.go", `package app
+
+import "example.com/precommit/pb"
+
+func A(m *pb.Msg) string {
+	return m.Name
+}
+`)
+		Git("add", ".")
+		rc, findings := Precommit(root, "--", "pb")
+		Expect(rc).To(Equal(exitUncheckedError))
+		Expect(Files(findings)).To(Equal([]string{"pb/pb.go"}))
+	})
+})